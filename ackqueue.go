@@ -0,0 +1,164 @@
+package nrf
+
+import (
+	"sync"
+	"time"
+)
+
+// AckPayload is ACK payload queued by AckQueue.
+type AckPayload struct {
+	Pipe int       // Number of Rx pipe.
+	Data []byte    // Payload.
+	Time time.Time // Time when payload was queued.
+}
+
+// AckQueue manages ACK payloads of PRX. It holds unbounded queue for every
+// pipe and keeps at most one payload per pipe in Tx FIFO, so it knows which
+// payload was sent with ACK for received packet. Receiving a new packet by
+// pipe pn means that payload written for pn has been sent to PTX that sent
+// this packet. Tx FIFO is refilled only when Rx FIFO is empty, so packets
+// received before payload was written aren't taken into account. Typical use
+// with Receiver:
+//
+//	rx.Handler[pn] = func(p nrf.Packet) { q.Received(p); ... }
+//	rx.OnTx = q.OnTx
+//
+// Only Push, Len and Flush can be called concurrently with other methods.
+// Other methods use Dev so they must be called by goroutine that uses Dev.
+type AckQueue struct {
+	Dev *Device
+
+	// Timeout, if > 0, is maximum time that payload can wait in queue. Stale
+	// payloads are removed from queue before they are written to Tx FIFO.
+	Timeout time.Duration
+
+	// Sent, if not nil, is called for every payload sent to PTX.
+	Sent func(AckPayload)
+
+	// Dropped, if not nil, is called for every stale payload.
+	Dropped func(AckPayload)
+
+	mu sync.Mutex
+	q  [6][]AckPayload
+	hw [6]*AckPayload // Payloads in Tx FIFO.
+}
+
+// Push appends copy of pay to queue of pipe pn.
+func (q *AckQueue) Push(pn int, pay []byte) error {
+	if err := checkPN(pn); err != nil {
+		return err
+	}
+	if err := checkPlen(len(pay)); err != nil {
+		return err
+	}
+	p := AckPayload{pn, append([]byte(nil), pay...), time.Now()}
+	q.mu.Lock()
+	q.q[pn] = append(q.q[pn], p)
+	q.mu.Unlock()
+	return nil
+}
+
+// Len returns number of payloads queued for pipe pn, including payload
+// written to Tx FIFO.
+func (q *AckQueue) Len(pn int) (int, error) {
+	if err := checkPN(pn); err != nil {
+		return 0, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := len(q.q[pn])
+	if q.hw[pn] != nil {
+		n++
+	}
+	return n, nil
+}
+
+// Flush removes all payloads from queue of pipe pn. Payload already written
+// to Tx FIFO stays there.
+func (q *AckQueue) Flush(pn int) error {
+	if err := checkPN(pn); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	q.q[pn] = nil
+	q.mu.Unlock()
+	return nil
+}
+
+// Received should be called for every packet received by PRX. It reports
+// payload sent with ACK for p (if any) and refills Tx FIFO.
+func (q *AckQueue) Received(p Packet) error {
+	if err := checkPN(p.Pipe); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	sent := q.hw[p.Pipe]
+	q.hw[p.Pipe] = nil
+	q.mu.Unlock()
+	if sent != nil && q.Sent != nil {
+		q.Sent(*sent)
+	}
+	return q.Refill()
+}
+
+// OnTx can be used as Receiver.OnTx. It clears TxDS and MaxRT flags and
+// refills Tx FIFO.
+func (q *AckQueue) OnTx(s Status) {
+	q.Dev.Clear(s & (TxDS | MaxRT))
+	q.Refill()
+}
+
+// Refill removes stale payloads from queues and writes first payload from
+// every queue that has no payload in Tx FIFO. It does nothing if Rx FIFO
+// isn't empty. It should be called after Push if PRX can receive no more
+// packets for a long time.
+func (q *AckQueue) Refill() error {
+	d := q.Dev
+	var stale []AckPayload
+	q.mu.Lock()
+	if q.Timeout > 0 {
+		now := time.Now()
+		for pn := range q.q {
+			k := 0
+			for _, p := range q.q[pn] {
+				if now.Sub(p.Time) > q.Timeout {
+					stale = append(stale, p)
+				} else {
+					q.q[pn][k] = p
+					k++
+				}
+			}
+			q.q[pn] = q.q[pn][:k]
+		}
+	}
+	for pn := range q.q {
+		if q.hw[pn] != nil || len(q.q[pn]) == 0 {
+			continue
+		}
+		if d.FIFO()&(TxFull|RxEmpty) != RxEmpty || d.Err != nil {
+			break
+		}
+		p := q.q[pn][0]
+		d.WriteAckP(pn, p.Data)
+		if d.Err != nil {
+			break
+		}
+		q.hw[pn] = &p
+		q.q[pn] = q.q[pn][1:]
+	}
+	q.mu.Unlock()
+	if q.Dropped != nil {
+		for _, p := range stale {
+			q.Dropped(p)
+		}
+	}
+	return d.Err
+}
+
+// Reset forgets payloads written to Tx FIFO. It should be called after Tx
+// FIFO was flushed.
+func (q *AckQueue) Reset() {
+	q.mu.Lock()
+	q.hw = [6]*AckPayload{}
+	q.mu.Unlock()
+}
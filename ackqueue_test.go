@@ -0,0 +1,112 @@
+package nrf_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ziutek/nrf"
+)
+
+// recvAck reads payload received by PRX b and reports it to q.
+func recvAck(t *testing.T, b *nrf.Device, q *nrf.AckQueue) {
+	t.Helper()
+	b.NOP()
+	pn := b.RxPipe()
+	pay := make([]byte, b.RxPLen())
+	b.ReadRxP(pay)
+	b.Clear(nrf.RxDR)
+	if b.Err != nil {
+		t.Fatal(b.Err)
+	}
+	if err := q.Received(nrf.Packet{Pipe: pn, Data: pay}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAckQueue(t *testing.T) {
+	a, b := link(t, 3)
+	if err := b.SetCE(1); err != nil {
+		t.Fatal(err)
+	}
+	var sent []string
+	q := &nrf.AckQueue{
+		Dev:  b,
+		Sent: func(p nrf.AckPayload) { sent = append(sent, string(p.Data)) },
+	}
+	for _, s := range []string{"a", "b"} {
+		if err := q.Push(0, []byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.Refill(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := q.Len(0); n != 2 || err != nil {
+		t.Fatalf("Len(0) = %d, %v", n, err)
+	}
+	tx := &nrf.Transmitter{Dev: a}
+	for _, want := range []string{"a", "b", ""} {
+		res, err := tx.Send(context.Background(), []byte("x"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(res.AckPay) != want {
+			t.Errorf("ACK payload %q, want %q", res.AckPay, want)
+		}
+		recvAck(t, b, q)
+	}
+	if len(sent) != 2 || sent[0] != "a" || sent[1] != "b" {
+		t.Errorf("Sent called for %q", sent)
+	}
+	if n, _ := q.Len(0); n != 0 {
+		t.Errorf("Len(0) = %d after all payloads sent", n)
+	}
+}
+
+func TestAckQueueTimeout(t *testing.T) {
+	_, b := link(t, 3)
+	var dropped []string
+	q := &nrf.AckQueue{
+		Dev:     b,
+		Timeout: time.Millisecond,
+		Dropped: func(p nrf.AckPayload) { dropped = append(dropped, string(p.Data)) },
+	}
+	q.Push(1, []byte("stale"))
+	time.Sleep(2 * time.Millisecond)
+	q.Push(1, []byte("fresh"))
+	if err := q.Refill(); err != nil {
+		t.Fatal(err)
+	}
+	if len(dropped) != 1 || dropped[0] != "stale" {
+		t.Errorf("Dropped called for %q", dropped)
+	}
+	if n, _ := q.Len(1); n != 1 {
+		t.Errorf("Len(1) = %d, want 1", n)
+	}
+	if err := q.Flush(1); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := q.Len(1); n != 1 {
+		t.Errorf("Flush removed payload from Tx FIFO: Len(1) = %d", n)
+	}
+}
+
+func TestAckQueuePipeRange(t *testing.T) {
+	q := &nrf.AckQueue{}
+	for _, pn := range []int{-1, 6} {
+		if err := q.Push(pn, nil); !errors.Is(err, nrf.ErrPipeRange) {
+			t.Errorf("Push(%d): %v", pn, err)
+		}
+		if _, err := q.Len(pn); !errors.Is(err, nrf.ErrPipeRange) {
+			t.Errorf("Len(%d): %v", pn, err)
+		}
+		if err := q.Flush(pn); !errors.Is(err, nrf.ErrPipeRange) {
+			t.Errorf("Flush(%d): %v", pn, err)
+		}
+		if err := q.Received(nrf.Packet{Pipe: pn}); !errors.Is(err, nrf.ErrPipeRange) {
+			t.Errorf("Received(Pipe: %d): %v", pn, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package nrf
+
+import "encoding/binary"
+
+// Bank1Init contains values of Beken register bank 1 registers 0x00-0x0e in
+// order they are sent over SPI. Nil values aren't written.
+type Bank1Init [15][]byte
+
+// BK2421Init contains bank 1 values recommended for BK2421 (RFM70) by vendor
+// sample code. For other Beken chips use values from their datasheets.
+var BK2421Init = Bank1Init{
+	{0x40, 0x4b, 0x01, 0xe2},
+	{0xc0, 0x4b, 0x00, 0x00},
+	{0xd0, 0xfc, 0x8c, 0x02},
+	{0x99, 0x00, 0x39, 0x41},
+	{0xf9, 0x9e, 0x86, 0x0b},
+	{0x24, 0x06, 0x7f, 0xa6},
+	{0x00, 0x00, 0x00, 0x00},
+	{0x00, 0x00, 0x00, 0x00},
+	{0x00, 0x00, 0x00, 0x00},
+	{0x00, 0x00, 0x00, 0x00},
+	{0x00, 0x00, 0x00, 0x00},
+	{0x00, 0x00, 0x00, 0x00},
+	{0x00, 0x12, 0x73, 0x00},
+	{0x36, 0xb4, 0x80, 0x00},
+	{0x41, 0x20, 0x08, 0x04, 0x81, 0x20, 0xcf, 0xf7, 0xfe, 0xff, 0xff},
+}
+
+// Bank returns selected register bank of Beken chip (RBANK bit in STATUS).
+// It always returns 0 for nRF24L01(+).
+func (d *Device) Bank() int {
+	d.NOP()
+	if d.Status&rbank != 0 {
+		return 1
+	}
+	return 0
+}
+
+// SelectBank selects register bank of Beken chip using ACTIVATE 0x53. Use
+// Bank1 methods instead of SelectBank(1) if possible: they always select bank
+// 0 before return. SelectBank(1) must not be used with d.Cache.
+func (d *Device) SelectBank(bank int) {
+	if d.Bank() != bank&1 {
+		d.Activate(0x53)
+	}
+}
+
+// bank0 selects register bank 0 even if d.Err != nil.
+func (d *Device) bank0() {
+	r := d.radio()
+	if s, err := r.NOP(); err == nil && s&rbank != 0 {
+		r.Activate(0x53)
+	}
+}
+
+// Bank1 selects register bank 1, calls f and selects bank 0. d.Cache is
+// disabled during this call.
+func (d *Device) Bank1(f func()) {
+	d.noCache(func() {
+		d.SelectBank(1)
+		if d.Err == nil {
+			f()
+		}
+		d.bank0()
+	})
+}
+
+// Bank1Reg reads value of bank 1 register.
+func (d *Device) Bank1Reg(addr byte, val []byte) {
+	d.Bank1(func() { d.Reg(addr, val) })
+}
+
+// SetBank1Reg writes value of bank 1 register.
+func (d *Device) SetBank1Reg(addr byte, val ...byte) {
+	d.Bank1(func() { d.SetReg(addr, val...) })
+}
+
+// ChipID returns chip ID from bank 1 register 0x08.
+func (d *Device) ChipID() uint32 {
+	var id [4]byte
+	d.Bank1Reg(8, id[:])
+	return binary.LittleEndian.Uint32(id[:])
+}
+
+// InitBank1 writes init to bank 1 registers and next toggles two bits of
+// register 0x04 as vendor sample code does.
+func (d *Device) InitBank1(init *Bank1Init) {
+	d.Bank1(func() {
+		for addr, val := range init {
+			if val != nil {
+				d.SetReg(byte(addr), val...)
+			}
+		}
+		if r4 := init[4]; len(r4) == 4 {
+			t := append([]byte(nil), r4...)
+			t[0] |= 0x06
+			d.SetReg(4, t...)
+			t[0] &^= 0x06
+			d.SetReg(4, t...)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package nrf_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+// beken emulates register bank 1 of Beken chip on top of emulated nRF24L01+.
+type beken struct {
+	*emu.Chip
+	bank1 bool
+	reg   [15][]byte // Bank 1 registers.
+	log   [][]byte   // Values written to bank 1 register 4.
+}
+
+func newBeken() *beken {
+	b := &beken{Chip: emu.NewChip()}
+	b.reg[8] = []byte{0x63, 0, 0, 0}
+	return b
+}
+
+func (b *beken) WriteRead(oi ...[]byte) (int, error) {
+	cmd := oi[0]
+	if b.bank1 && cmd[0] < 0x40 && int(cmd[0]&0x1f) < len(b.reg) {
+		addr := cmd[0] & 0x1f
+		if cmd[0]&0x20 == 0 {
+			copy(oi[3], b.reg[addr])
+		} else {
+			b.reg[addr] = append([]byte(nil), oi[2]...)
+			if addr == 4 {
+				b.log = append(b.log, b.reg[addr])
+			}
+		}
+		oi = [][]byte{{0xff}, oi[1]} // NOP returns STATUS.
+	}
+	n, err := b.Chip.WriteRead(oi...)
+	if len(cmd) == 2 && cmd[0] == 0x50 && cmd[1] == 0x53 {
+		b.bank1 = !b.bank1
+	}
+	if b.bank1 && len(oi) > 1 && len(oi[1]) > 0 {
+		oi[1][0] |= 0x80
+	}
+	return n, err
+}
+
+func TestBankNRF(t *testing.T) {
+	d := &nrf.Device{Driver: emu.NewChip()}
+	d.SelectBank(1)
+	if b := d.Bank(); b != 0 || d.Err != nil {
+		t.Errorf("Bank: %d, %v", b, d.Err)
+	}
+}
+
+func TestBank1(t *testing.T) {
+	drv := newBeken()
+	d := &nrf.Device{Driver: drv}
+	d.SetCh(7)
+	if id := d.ChipID(); id != 0x63 {
+		t.Errorf("ChipID: %#x", id)
+	}
+	d.SetBank1Reg(5, 1, 2, 3, 4)
+	var r5 [4]byte
+	d.Bank1Reg(5, r5[:])
+	if r5 != [4]byte{1, 2, 3, 4} {
+		t.Errorf("bank 1 register 5: %x", r5)
+	}
+	if ch := d.Ch(); ch != 7 || d.Bank() != 0 || d.Err != nil {
+		t.Errorf("bank 0: RF_CH %d, bank %d, %v", ch, d.Bank(), d.Err)
+	}
+	d.SelectBank(1)
+	if b := d.Bank(); b != 1 {
+		t.Errorf("Bank after SelectBank(1): %d", b)
+	}
+	d.SelectBank(0)
+	if v := d.Probe(); v.Model != nrf.Beken || v.Caps&nrf.CapBank1 == 0 || v.ID != 0x63 {
+		t.Errorf("Probe: %v", v)
+	}
+}
+
+func TestBank1Error(t *testing.T) {
+	d := &nrf.Device{Driver: newBeken()}
+	fail := errors.New("failure")
+	d.Bank1(func() { d.Err = fail })
+	d.Err = nil
+	if b := d.Bank(); b != 0 {
+		t.Errorf("bank %d selected after error", b)
+	}
+}
+
+func TestInitBank1(t *testing.T) {
+	drv := newBeken()
+	d := &nrf.Device{Driver: drv}
+	init := nrf.BK2421Init
+	d.InitBank1(&init)
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	for addr, val := range init {
+		if !bytes.Equal(drv.reg[addr], val) {
+			t.Errorf("register %#x: %x, want %x", addr, drv.reg[addr], val)
+		}
+	}
+	if len(drv.log) != 3 || drv.log[1][0] != init[4][0]|0x06 {
+		t.Errorf("writes of register 4: %x", drv.log)
+	}
+	if d.Bank() != 0 {
+		t.Error("bank 1 left selected")
+	}
+}
@@ -0,0 +1,201 @@
+package nrf
+
+// Result is deferred result of command queued in Batch. Its fields are valid
+// after Batch is executed.
+type Result struct {
+	Status Status // STATUS register read by command.
+	Val    []byte // Value read by command (nil if command reads nothing).
+	stat   [1]byte
+}
+
+// Byte returns first byte of r.Val or 0 if r.Val is empty.
+func (r *Result) Byte() byte {
+	if len(r.Val) == 0 {
+		return 0
+	}
+	return r.Val[0]
+}
+
+// Batch queues commands to execute them in one Batcher.WriteReadBatch call.
+// Methods that read something return Result that is filled when Batch is
+// executed. Invalid arguments are reported by Exec. Zero value of Batch is
+// empty batch ready to use.
+type Batch struct {
+	txs [][][]byte
+	res []*Result
+	err error
+}
+
+// Len returns number of queued commands.
+func (b *Batch) Len() int {
+	return len(b.txs)
+}
+
+// Reset removes all commands from b.
+func (b *Batch) Reset() {
+	b.txs = b.txs[:0]
+	b.res = b.res[:0]
+	b.err = nil
+}
+
+func (b *Batch) add(c byte, out []byte, in []byte) *Result {
+	r := &Result{Val: in}
+	oi := [][]byte{{c}, r.stat[:]}
+	if out != nil || in != nil {
+		oi = append(oi, out, in)
+	}
+	b.txs = append(b.txs, oi)
+	b.res = append(b.res, r)
+	return r
+}
+
+func (b *Batch) check(err error) bool {
+	if b.err == nil {
+		b.err = err
+	}
+	return err == nil
+}
+
+// Reg queues R_REGISTER command that reads n bytes.
+func (b *Batch) Reg(addr byte, n int) *Result {
+	if n < 0 {
+		b.check(argErr(ErrRegLen, n))
+		n = 0
+	}
+	return b.add(addr, nil, make([]byte, n))
+}
+
+// SetReg queues W_REGISTER command. val is copied.
+func (b *Batch) SetReg(addr byte, val ...byte) *Result {
+	return b.add(addr|0x20, append([]byte(nil), val...), nil)
+}
+
+// Clear queues command that clears specified bits in STATUS register.
+func (b *Batch) Clear(stat Status) *Result {
+	return b.SetReg(7, byte(stat))
+}
+
+// ReadRxP queues R_RX_PAYLOAD command that reads n bytes.
+func (b *Batch) ReadRxP(n int) *Result {
+	if !b.check(checkPW(n)) {
+		n = 0
+	}
+	return b.add(0x61, nil, make([]byte, n))
+}
+
+// WriteTxP queues W_TX_PAYLOAD command. pay is copied.
+func (b *Batch) WriteTxP(pay []byte) *Result {
+	b.check(checkPlen(len(pay)))
+	return b.add(0xa0, append([]byte(nil), pay...), nil)
+}
+
+// WriteTxPNoAck queues W_TX_PAYLOAD_NOACK command. pay is copied.
+func (b *Batch) WriteTxPNoAck(pay []byte) *Result {
+	b.check(checkPlen(len(pay)))
+	return b.add(0xb0, append([]byte(nil), pay...), nil)
+}
+
+// WriteAckP queues W_ACK_PAYLOAD command. pay is copied.
+func (b *Batch) WriteAckP(pn int, pay []byte) *Result {
+	if !b.check(checkPN(pn)) {
+		pn = 0
+	}
+	b.check(checkPlen(len(pay)))
+	return b.add(byte(0xa8|pn), append([]byte(nil), pay...), nil)
+}
+
+// RxPLen queues R_RX_PL_WID command. Result.Byte returns payload length.
+func (b *Batch) RxPLen() *Result {
+	return b.add(0x60, nil, make([]byte, 1))
+}
+
+// FlushTx queues FLUSH_TX command.
+func (b *Batch) FlushTx() *Result {
+	return b.add(0xe1, nil, nil)
+}
+
+// FlushRx queues FLUSH_RX command.
+func (b *Batch) FlushRx() *Result {
+	return b.add(0xe2, nil, nil)
+}
+
+// ReuseTxP queues REUSE_TX_PL command.
+func (b *Batch) ReuseTxP() *Result {
+	return b.add(0xe3, nil, nil)
+}
+
+// Activate queues nRF24L01 ACTIVATE command.
+func (b *Batch) Activate(v byte) *Result {
+	return b.add(0x50, []byte{v}, nil)
+}
+
+// NOP queues NOP command.
+func (b *Batch) NOP() *Result {
+	return b.add(0xff, nil, nil)
+}
+
+// Exec executes commands queued in b. It uses Batcher interface if
+// implemented by r.Driver. Otherwise it calls WriteRead for every command
+// and stops at first error. It returns STATUS read by last command. Exec
+// doesn't reset b.
+func (r Radio) Exec(b *Batch) (Status, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if len(b.txs) == 0 {
+		return 0, nil
+	}
+	var err error
+	if bd, ok := r.Driver.(Batcher); ok {
+		err = bd.WriteReadBatch(b.txs...)
+	} else {
+		for _, oi := range b.txs {
+			if _, err = r.WriteRead(oi...); err != nil {
+				break
+			}
+		}
+	}
+	for _, res := range b.res {
+		res.Status = Status(res.stat[0])
+	}
+	return b.res[len(b.res)-1].Status, err
+}
+
+// Exec executes commands queued in b (see Radio.Exec). Registers written or
+// read by b are stored in d.Cache. ACTIVATE command or error invalidates
+// d.Cache.
+func (d *Device) Exec(b *Batch) {
+	if d.Err != nil {
+		return
+	}
+	d.Status, d.Err = d.radio().Exec(b)
+	if d.Cache != nil {
+		b.update(d.Cache, d.Err != nil)
+	}
+}
+
+// update stores values of registers accessed by b in c. It invalidates c if
+// b contains ACTIVATE command (it can select Beken register bank 1) or if
+// invalid is true.
+func (b *Batch) update(c *RegCache, invalid bool) {
+	for _, oi := range b.txs {
+		if oi[0][0] == 0x50 {
+			invalid = true
+		}
+	}
+	if invalid {
+		c.Invalidate()
+		return
+	}
+	for _, oi := range b.txs {
+		cmd := oi[0][0]
+		if cmd >= 0x40 || len(oi) < 4 {
+			continue
+		}
+		if cmd < 0x20 {
+			c.put(cmd, oi[3]) // R_REGISTER
+		} else {
+			c.put(cmd&0x1f, oi[2]) // W_REGISTER
+		}
+	}
+}
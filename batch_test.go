@@ -0,0 +1,122 @@
+package nrf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+// counter counts WriteRead and WriteReadBatch calls of emulated chip. If fail
+// is not nil it is returned by WriteRead call number failAt.
+type counter struct {
+	*emu.Chip
+	reads, batches int
+	failAt         int
+	fail           error
+}
+
+func (c *counter) WriteRead(oi ...[]byte) (int, error) {
+	c.reads++
+	if c.fail != nil && c.reads == c.failAt {
+		return 0, c.fail
+	}
+	return c.Chip.WriteRead(oi...)
+}
+
+func (c *counter) WriteReadBatch(txs ...[][]byte) error {
+	c.batches++
+	return c.Chip.WriteReadBatch(txs...)
+}
+
+// noBatch hides Batcher implemented by c.
+type noBatch struct {
+	c *counter
+}
+
+func (d noBatch) WriteRead(oi ...[]byte) (int, error) { return d.c.WriteRead(oi...) }
+func (d noBatch) SetCE(v int) error                   { return d.c.SetCE(v) }
+
+func TestBatch(t *testing.T) {
+	for _, batcher := range []bool{true, false} {
+		c := &counter{Chip: emu.NewChip()}
+		d := &nrf.Device{Driver: c}
+		if !batcher {
+			d.Driver = noBatch{c}
+		}
+		var b nrf.Batch
+		b.SetReg(5, 42)
+		ch := b.Reg(5, 1)
+		b.WriteTxP([]byte{1, 2, 3})
+		b.WriteTxP([]byte{4})
+		fifo := b.Reg(0x17, 1)
+		b.FlushTx()
+		nop := b.NOP()
+		if b.Len() != 7 {
+			t.Fatalf("Len: %d", b.Len())
+		}
+		d.Exec(&b)
+		if d.Err != nil {
+			t.Fatal(d.Err)
+		}
+		if ch.Byte() != 42 || nrf.FIFO(fifo.Byte())&nrf.TxEmpty != 0 {
+			t.Errorf("batcher=%t: RF_CH %d, FIFO_STATUS %v", batcher, ch.Byte(), nrf.FIFO(fifo.Byte()))
+		}
+		if fifo.Status&nrf.FullTx != 0 || nop.Val != nil || d.Status != nop.Status {
+			t.Errorf("batcher=%t: STATUS %v, %v, %v", batcher, fifo.Status, nop.Status, d.Status)
+		}
+		if batcher && (c.batches != 1 || c.reads != 0) {
+			t.Errorf("%d batches, %d reads, want one batch", c.batches, c.reads)
+		}
+		if !batcher && (c.batches != 0 || c.reads != 7) {
+			t.Errorf("%d batches, %d reads, want 7 reads", c.batches, c.reads)
+		}
+		if d.FIFO()&nrf.TxEmpty == 0 {
+			t.Errorf("batcher=%t: FLUSH_TX not executed", batcher)
+		}
+		b.Reset()
+		if b.Len() != 0 {
+			t.Errorf("Len after Reset: %d", b.Len())
+		}
+	}
+}
+
+func TestBatchInvalid(t *testing.T) {
+	c := &counter{Chip: emu.NewChip()}
+	r := nrf.Radio{Driver: c}
+	var b nrf.Batch
+	b.NOP()
+	b.WriteTxP(make([]byte, 33))
+	b.WriteAckP(6, nil)
+	_, err := r.Exec(&b)
+	var ae *nrf.ArgError
+	if !errors.Is(err, nrf.ErrPayloadTooLong) || !errors.As(err, &ae) || ae.Value != 33 {
+		t.Errorf("error %v, want ErrPayloadTooLong", err)
+	}
+	if c.batches != 0 || c.reads != 0 {
+		t.Error("invalid batch executed")
+	}
+	b.Reset()
+	if s, err := r.Exec(&b); s != 0 || err != nil {
+		t.Errorf("empty batch: %v, %v", s, err)
+	}
+}
+
+func TestBatchFallbackError(t *testing.T) {
+	c := &counter{Chip: emu.NewChip(), failAt: 2, fail: errors.New("SPI failure")}
+	r := nrf.Radio{Driver: noBatch{c}}
+	var b nrf.Batch
+	b.SetReg(5, 1)
+	b.SetReg(5, 2)
+	b.SetReg(5, 3)
+	if _, err := r.Exec(&b); err != c.fail {
+		t.Errorf("error %v, want %v", err, c.fail)
+	}
+	if c.reads != 2 {
+		t.Errorf("%d commands executed after error", c.reads-2)
+	}
+	if ch, _, _ := r.Ch(); ch != 1 {
+		t.Errorf("RF_CH = %d, want 1", ch)
+	}
+}
@@ -0,0 +1,164 @@
+package nrf
+
+// writeMask contains writable bits of one-byte nRF24L01+ registers (0xff
+// for address registers). Zero mask means read-only or reserved register.
+var writeMask = [0x1e]byte{
+	0x00: 0x7f, // CONFIG
+	0x01: 0x3f, // EN_AA
+	0x02: 0x3f, // EN_RXADDR
+	0x03: 0x03, // SETUP_AW
+	0x04: 0xff, // SETUP_RETR
+	0x05: 0x7f, // RF_CH
+	0x06: 0xbf, // RF_SETUP
+	0x07: 0x70, // STATUS
+	0x0a: 0xff, // RX_ADDR_P0
+	0x0b: 0xff, // RX_ADDR_P1
+	0x0c: 0xff, // RX_ADDR_P2
+	0x0d: 0xff, // RX_ADDR_P3
+	0x0e: 0xff, // RX_ADDR_P4
+	0x0f: 0xff, // RX_ADDR_P5
+	0x10: 0xff, // TX_ADDR
+	0x11: 0x3f, // RX_PW_P0
+	0x12: 0x3f, // RX_PW_P1
+	0x13: 0x3f, // RX_PW_P2
+	0x14: 0x3f, // RX_PW_P3
+	0x15: 0x3f, // RX_PW_P4
+	0x16: 0x3f, // RX_PW_P5
+	0x1c: 0x3f, // DYNPD
+	0x1d: 0x07, // FEATURE
+}
+
+// RegCache contains values of registers that chip never changes itself. It
+// is used by Device if Device.Cache isn't nil. STATUS, OBSERVE_TX, RPD and
+// FIFO_STATUS registers are never cached. Zero value is an empty cache.
+type RegCache struct {
+	val [0x1e][5]byte
+	n   [0x1e]int // Number of valid bytes.
+
+	through bool // Read through cache.
+}
+
+// Invalidate removes all values from c. It should be called after chip was
+// reset or written without using Device that uses c.
+func (c *RegCache) Invalidate() {
+	c.n = [0x1e]int{}
+}
+
+func (c *RegCache) get(addr byte, val []byte) bool {
+	if c.through || int(addr) >= len(c.n) || c.n[addr] < len(val) {
+		return false
+	}
+	copy(val, c.val[addr][:])
+	return true
+}
+
+func (c *RegCache) put(addr byte, val []byte) {
+	if int(addr) >= len(c.n) || volatile(addr) || len(val) == 0 {
+		return
+	}
+	if len(val) > len(c.val[addr]) {
+		val = val[:len(c.val[addr])]
+	}
+	n := copy(c.val[addr][:], val)
+	c.val[addr][0] &= writeMask[addr]
+	if n > c.n[addr] {
+		c.n[addr] = n
+	}
+}
+
+// volatile reports whether register at addr isn't cached: it is read-only,
+// reserved or can be changed by chip itself.
+func volatile(addr byte) bool {
+	return addr == 7 || writeMask[addr] == 0
+}
+
+// readThrough calls f with d.Cache (if any) in read through mode: values are
+// read from chip and stored in cache.
+func (d *Device) readThrough(f func()) {
+	c := d.Cache
+	if c == nil {
+		f()
+		return
+	}
+	through := c.through
+	c.through = true
+	f()
+	c.through = through
+}
+
+// noCache calls f with d.Cache disabled.
+func (d *Device) noCache(f func()) {
+	c := d.Cache
+	d.Cache = nil
+	f()
+	d.Cache = c
+}
+
+// Resync reads all cached registers from chip into d.Cache.
+func (d *Device) Resync() {
+	if d.Cache == nil {
+		return
+	}
+	d.readThrough(func() {
+		var buf [5]byte
+		for addr := range writeMask {
+			if volatile(byte(addr)) {
+				continue
+			}
+			n := 1
+			if addr == 0x0a || addr == 0x0b || addr == 0x10 {
+				n = 5
+			}
+			d.Reg(byte(addr), buf[:n])
+		}
+	})
+}
+
+// modify sets bits from set and clears bits from clear in register at addr.
+// It performs one SPI transaction if value of register is in d.Cache.
+func (d *Device) modify(addr byte, set, clear byte) {
+	v := d.byteReg(addr)
+	d.SetReg(addr, v&^clear|set)
+}
+
+// SetCfgBits sets specified bits in CONFIG register.
+func (d *Device) SetCfgBits(c Config) {
+	d.modify(0, byte(c), 0)
+}
+
+// ClearCfgBits clears specified bits in CONFIG register.
+func (d *Device) ClearCfgBits(c Config) {
+	d.modify(0, 0, byte(c))
+}
+
+// SetRFBits sets specified bits in RF_SETUP register.
+func (d *Device) SetRFBits(rf RF) {
+	d.modify(6, byte(rf), 0)
+}
+
+// ClearRFBits clears specified bits in RF_SETUP register.
+func (d *Device) ClearRFBits(rf RF) {
+	d.modify(6, 0, byte(rf))
+}
+
+// SetFeatureBits sets specified bits in FEATURE register.
+func (d *Device) SetFeatureBits(f Feature) {
+	d.modify(0x1d, byte(f), 0)
+}
+
+// ClearFeatureBits clears specified bits in FEATURE register.
+func (d *Device) ClearFeatureBits(f Feature) {
+	d.modify(0x1d, 0, byte(f))
+}
+
+// SetPipeBits sets specified bits in EN_AA (addr=0x01), EN_RXADDR (0x02) or
+// DYNPD (0x1c) register.
+func (d *Device) SetPipeBits(addr byte, p Pipe) {
+	d.modify(addr, byte(p), 0)
+}
+
+// ClearPipeBits clears specified bits in EN_AA (addr=0x01), EN_RXADDR (0x02)
+// or DYNPD (0x1c) register.
+func (d *Device) ClearPipeBits(addr byte, p Pipe) {
+	d.modify(addr, 0, byte(p))
+}
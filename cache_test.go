@@ -0,0 +1,116 @@
+package nrf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+// cached returns device that uses register cache and counter of its SPI
+// transactions.
+func cached() (*nrf.Device, *counter) {
+	c := &counter{Chip: emu.NewChip()}
+	return &nrf.Device{Driver: c, Cache: new(nrf.RegCache)}, c
+}
+
+// checkCache checks that RF_CH, RF_SETUP and TX_ADDR are read from d.Cache
+// and that cached values are equal to values in chip.
+func checkCache(t *testing.T, d *nrf.Device, c *counter) {
+	t.Helper()
+	n := c.reads
+	ch, rf := d.Ch(), d.RF()
+	var addr [5]byte
+	d.TxAddr(addr[:])
+	if c.reads != n {
+		t.Errorf("%d registers read from chip", c.reads-n)
+	}
+	chip := &nrf.Device{Driver: c.Chip}
+	var chipAddr [5]byte
+	chip.TxAddr(chipAddr[:])
+	if ch != chip.Ch() || rf != chip.RF() || addr != chipAddr {
+		t.Errorf("cache: %d %v %x, chip: %d %v %x", ch, rf, addr, chip.Ch(), chip.RF(), chipAddr)
+	}
+}
+
+func TestCache(t *testing.T) {
+	d, c := cached()
+	d.Resync()
+	checkCache(t, d, c)
+	d.SetCh(33)
+	d.SetRFBits(nrf.DRLow)
+	d.SetTxAddr(1, 2, 3, 4, 5)
+	checkCache(t, d, c)
+	n := c.reads
+	d.SetCfgBits(nrf.PwrUp)
+	d.ClearCfgBits(nrf.EnCRC)
+	if c.reads != n+2 {
+		t.Errorf("%d transactions, want 2", c.reads-n)
+	}
+	if cfg := (&nrf.Device{Driver: c.Chip}).Config(); cfg != nrf.PwrUp {
+		t.Errorf("CONFIG = %v", cfg)
+	}
+	n = c.reads
+	d.FIFO()
+	d.FIFO()
+	if c.reads != n+2 {
+		t.Error("FIFO_STATUS cached")
+	}
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+}
+
+func TestCacheExec(t *testing.T) {
+	d, c := cached()
+	d.Resync()
+	var b nrf.Batch
+	b.SetReg(5, 9)
+	b.SetReg(0x10, 5, 4, 3, 2, 1)
+	b.SetReg(6, 0x26)
+	d.Exec(&b)
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	checkCache(t, d, c)
+
+	b.Reset()
+	b.SetReg(5, 10)
+	b.Activate(0x73)
+	d.Exec(&b)
+	n := c.reads
+	if ch := d.Ch(); ch != 10 || c.reads != n+1 {
+		t.Errorf("RF_CH %d read from invalidated cache", ch)
+	}
+
+	c.failAt, c.fail = c.reads+2, errors.New("SPI failure")
+	d.Driver = noBatch{c}
+	b.Reset()
+	b.SetReg(5, 11)
+	b.SetReg(5, 12)
+	d.Exec(&b)
+	if d.Err != c.fail {
+		t.Fatalf("error %v, want %v", d.Err, c.fail)
+	}
+	d.Err = nil
+	if ch := d.Ch(); ch != 11 {
+		t.Errorf("RF_CH = %d after failed batch", ch)
+	}
+}
+
+func TestBatchLen(t *testing.T) {
+	var b nrf.Batch
+	r := b.Reg(5, -1)
+	if r.Byte() != 0 {
+		t.Errorf("Byte of empty Val: %d", r.Byte())
+	}
+	if _, err := (nrf.Radio{Driver: emu.NewChip()}).Exec(&b); !errors.Is(err, nrf.ErrRegLen) {
+		t.Errorf("Reg(5, -1): %v", err)
+	}
+	b.Reset()
+	b.ReadRxP(33)
+	if _, err := (nrf.Radio{Driver: emu.NewChip()}).Exec(&b); !errors.Is(err, nrf.ErrPayloadWidth) {
+		t.Errorf("ReadRxP(33): %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package nrf
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"sort"
+	"time"
+)
+
+// MaxLegalCh is the highest channel below 2483.5 MHz band edge.
+const MaxLegalCh = 83
+
+// Rank returns channels 0..MaxLegalCh sorted from the least to the most
+// occupied. Channels with equal occupancy are sorted by occupancy of
+// neighbouring channels (±2 MHz).
+func (s *Spectrum) Rank() []int {
+	var near [MaxLegalCh + 1]float64
+	for ch := range near {
+		for n := ch - 2; n <= ch+2; n++ {
+			if n >= 0 && n < NumCh {
+				near[ch] += s[n]
+			}
+		}
+	}
+	chs := make([]int, MaxLegalCh+1)
+	for ch := range chs {
+		chs[ch] = ch
+	}
+	sort.SliceStable(chs, func(i, k int) bool {
+		a, b := chs[i], chs[k]
+		if s[a] != s[b] {
+			return s[a] < s[b]
+		}
+		return near[a] < near[b]
+	})
+	return chs
+}
+
+// Best returns the least occupied channel (see Rank).
+func (s *Spectrum) Best() int {
+	return s.Rank()[0]
+}
+
+// ErrChMove is returned by ChMove.UnmarshalBinary if data doesn't contain
+// channel migration command.
+var ErrChMove = errors.New("nrf: not a channel migration command")
+
+const (
+	chMoveMagic = 0xc4
+	chMoveLen   = 4
+)
+
+// ChMove is channel migration command sent by hub to its nodes. It is encoded
+// in 4-byte payload: 0xc4, channel, time to switch in milliseconds (uint16,
+// little endian), so applications should not use payloads that look like it.
+type ChMove struct {
+	Ch    int           // New channel.
+	After time.Duration // Time remaining to switch (max 65535 ms).
+}
+
+// MarshalBinary encodes m.
+func (m ChMove) MarshalBinary() ([]byte, error) {
+	if err := checkCh(m.Ch); err != nil {
+		return nil, err
+	}
+	ms := m.After / time.Millisecond
+	if ms < 0 {
+		ms = 0
+	} else if ms > 0xffff {
+		ms = 0xffff
+	}
+	b := []byte{chMoveMagic, byte(m.Ch), 0, 0}
+	binary.LittleEndian.PutUint16(b[2:], uint16(ms))
+	return b, nil
+}
+
+// UnmarshalBinary decodes m from data.
+func (m *ChMove) UnmarshalBinary(data []byte) error {
+	if len(data) != chMoveLen || data[0] != chMoveMagic || data[1] > 127 {
+		return ErrChMove
+	}
+	m.Ch = int(data[1])
+	m.After = time.Duration(binary.LittleEndian.Uint16(data[2:])) * time.Millisecond
+	return nil
+}
+
+// At returns time of switch for command received at time t (eg. Packet.Time).
+func (m ChMove) At(t time.Time) time.Time {
+	return t.Add(m.After)
+}
+
+// Migrate moves network to channel ch at time at. It is used by hub that
+// works as PTX. Migrate sends ChMove command to every node address (node must
+// receive it on pipe with auto acknowledgment enabled) repeatedly, until all
+// nodes acknowledged it or time at is reached. Next it waits until at and
+// switches t.Dev to ch. It returns addresses of nodes that didn't acknowledge
+// the command (they can find network later using Rediscover). Every node
+// address must be AW bytes long. TX_ADDR and RX_ADDR_P0 are restored before
+// return.
+func Migrate(ctx context.Context, t *Transmitter, nodes [][]byte, ch int, at time.Time) (missed [][]byte, err error) {
+	d := t.Dev
+	if !d.check(checkCh(ch)) {
+		return nil, d.Err
+	}
+	aw := d.AW()
+	if !d.check(checkALen(aw)) {
+		return nil, d.Err // SETUP_AW contains illegal value.
+	}
+	var txAddr, rxAddr0 [5]byte
+	d.TxAddr(txAddr[:aw])
+	d.RxAddr(0, rxAddr0[:aw])
+	if d.Err != nil {
+		return nil, d.Err
+	}
+	for _, addr := range nodes {
+		if len(addr) != aw {
+			d.check(argErr(ErrAddrLen, len(addr)))
+			return nil, d.Err
+		}
+	}
+	missed = append(missed, nodes...)
+	for len(missed) != 0 {
+		now := time.Now()
+		if !now.Before(at) {
+			break
+		}
+		cmd, _ := ChMove{ch, at.Sub(now)}.MarshalBinary()
+		var still [][]byte
+		for i, addr := range missed {
+			d.SetTxAddr(addr...)
+			d.SetRxAddr(0, addr...)
+			_, err = t.Send(ctx, cmd)
+			if err == ErrMaxRetransmits {
+				still = append(still, addr)
+				err = nil
+				continue
+			}
+			if err != nil {
+				still = append(still, missed[i:]...) // Not sent.
+				break
+			}
+		}
+		missed = still
+		if err != nil {
+			break
+		}
+	}
+	d.SetTxAddr(txAddr[:aw]...)
+	d.SetRxAddr(0, rxAddr0[:aw]...)
+	if err == nil {
+		err = sleepUntil(ctx, at)
+	}
+	if err == nil {
+		d.SetCh(ch)
+	}
+	if d.Err != nil {
+		return missed, d.Err
+	}
+	return missed, err
+}
+
+func sleepUntil(ctx context.Context, at time.Time) error {
+	tm := time.NewTimer(time.Until(at))
+	defer tm.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-tm.C:
+		return nil
+	}
+}
+
+// Rediscover finds network after missed channel migration. It is used by
+// node that works as PRX. Rediscover listens on every channel from chs (all
+// legal channels if chs is empty) for dwell time, repeatedly, until a packet
+// is received (hub should send some traffic periodically, at least every
+// dwell). It returns channel on which packet was received and leaves this
+// packet in Rx FIFO, Dev in PRX mode and CE low.
+func Rediscover(ctx context.Context, d *Device, chs []int, dwell time.Duration) (int, error) {
+	if len(chs) == 0 {
+		chs = make([]int, MaxLegalCh+1)
+		for ch := range chs {
+			chs[ch] = ch
+		}
+	}
+	if d.Err != nil {
+		return -1, d.Err
+	}
+	if d.Err = d.SetCE(0); d.Err != nil {
+		return -1, d.Err
+	}
+	cfg := d.Config()
+	d.SetCfg(cfg | PwrUp | PrimRx)
+	if cfg&PwrUp == 0 {
+		time.Sleep(1500 * time.Microsecond) // Tpd2stby
+	}
+	d.FlushRx()
+	d.Clear(RxDR | TxDS | MaxRT)
+	for {
+		for _, ch := range chs {
+			d.SetCh(ch)
+			if d.Err != nil {
+				return -1, d.Err
+			}
+			if d.Err = d.SetCE(1); d.Err != nil {
+				return -1, d.Err
+			}
+			found, err := listen(ctx, d, dwell)
+			if e := d.SetCE(0); d.Err == nil {
+				d.Err = e
+			}
+			if d.Err != nil {
+				return -1, d.Err
+			}
+			if err != nil {
+				return -1, err
+			}
+			if found {
+				return ch, nil
+			}
+		}
+	}
+}
+
+// listen waits dwell for packet and reports whether it was received.
+func listen(ctx context.Context, d *Device, dwell time.Duration) (bool, error) {
+	wctx, cancel := context.WithTimeout(ctx, dwell)
+	defer cancel()
+	for {
+		err := d.WaitIRQ(wctx, 0)
+		if err != nil {
+			if d.Err == nil && ctx.Err() == nil {
+				err = nil
+			}
+			return false, err
+		}
+		if d.Status&RxDR != 0 {
+			return true, nil
+		}
+		d.Clear(TxDS | MaxRT)
+	}
+}
@@ -0,0 +1,159 @@
+package nrf_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+func TestSpectrumRank(t *testing.T) {
+	var s nrf.Spectrum
+	for ch := range s {
+		s[ch] = 1
+	}
+	s[30] = 0
+	s[60] = 0
+	s[59] = 0.5 // Neighbours of 60 are less occupied than neighbours of 30.
+	s[100] = 0  // Not legal.
+	r := s.Rank()
+	if len(r) != nrf.MaxLegalCh+1 || r[0] != 60 || r[1] != 30 || r[2] != 59 {
+		t.Errorf("Rank: %v", r)
+	}
+	if best := s.Best(); best != 60 {
+		t.Errorf("Best: %d, want 60", best)
+	}
+}
+
+func TestChMove(t *testing.T) {
+	m := nrf.ChMove{Ch: 42, After: 1500 * time.Millisecond}
+	data, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m1 nrf.ChMove
+	if err := m1.UnmarshalBinary(data); err != nil || m1 != m {
+		t.Errorf("round trip: %+v, %v", m1, err)
+	}
+	if err := m1.UnmarshalBinary([]byte("data")); err != nrf.ErrChMove {
+		t.Errorf("error %v, want ErrChMove", err)
+	}
+}
+
+// failAddr returns err when TX_ADDR is set to addr.
+type failAddr struct {
+	*emu.Chip
+	addr []byte
+	err  error
+}
+
+func (d failAddr) WriteRead(oi ...[]byte) (int, error) {
+	if oi[0][0] == 0x30 && bytes.Equal(oi[2], d.addr) {
+		return 0, d.err
+	}
+	return d.Chip.WriteRead(oi...)
+}
+
+func TestMigrate(t *testing.T) {
+	a, b := link(t, 1)
+	if err := b.SetCE(1); err != nil {
+		t.Fatal(err)
+	}
+	lost := []byte{7, 7, 7, 7, 7}
+	tx := &nrf.Transmitter{Dev: a}
+	at := time.Now().Add(20 * time.Millisecond)
+	missed, err := nrf.Migrate(context.Background(), tx, [][]byte{addr0, lost, addr1}, 50, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Now().Before(at) {
+		t.Error("Migrate returned too early")
+	}
+	if len(missed) != 1 || !bytes.Equal(missed[0], lost) {
+		t.Errorf("missed: %v", missed)
+	}
+	var txAddr [5]byte
+	a.TxAddr(txAddr[:])
+	if ch := a.Ch(); ch != 50 || txAddr != [5]byte{1, 2, 3, 4, 5} {
+		t.Errorf("RF_CH %d, TX_ADDR %v", ch, txAddr)
+	}
+	for pn := 0; pn < 2; pn++ {
+		b.NOP()
+		var m nrf.ChMove
+		pay := make([]byte, b.RxPLen())
+		b.ReadRxP(pay)
+		if err := m.UnmarshalBinary(pay); err != nil || m.Ch != 50 {
+			t.Errorf("node received %x: %v", pay, err)
+		}
+	}
+}
+
+func TestMigrateError(t *testing.T) {
+	a, _ := link(t, 1)
+	lost := []byte{7, 7, 7, 7, 7}
+	bad := []byte{8, 8, 8, 8, 8}
+	drv := failAddr{a.Driver.(*emu.Chip), bad, errors.New("SPI failure")}
+	a.Driver = drv
+	tx := &nrf.Transmitter{Dev: a}
+	at := time.Now().Add(time.Second)
+	missed, err := nrf.Migrate(context.Background(), tx, [][]byte{lost, bad, addr0}, 50, at)
+	if err != drv.err {
+		t.Errorf("error %v, want %v", err, drv.err)
+	}
+	want := [][]byte{lost, bad, addr0}
+	if len(missed) != len(want) {
+		t.Fatalf("missed: %v, want %v", missed, want)
+	}
+	for i, addr := range missed {
+		if !bytes.Equal(addr, want[i]) {
+			t.Errorf("missed: %v, want %v", missed, want)
+		}
+	}
+
+	a, _ = link(t, 1)
+	tx.Dev = a
+	_, err = nrf.Migrate(context.Background(), tx, [][]byte{addr0, {1, 2, 3, 4}}, 50, at)
+	var ae *nrf.ArgError
+	if !errors.Is(err, nrf.ErrAddrLen) || !errors.As(err, &ae) || ae.Value != 4 {
+		t.Errorf("error %v, want ErrAddrLen", err)
+	}
+}
+
+func TestRediscover(t *testing.T) {
+	a, b := link(t, 0)
+	a.SetCh(40)
+	b.SetCh(5)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tx := &nrf.Transmitter{Dev: a}
+		for ctx.Err() == nil {
+			tx.Send(ctx, []byte("hub"))
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	ch, err := nrf.Rediscover(ctx, b, []int{10, 40, 70}, 5*time.Millisecond)
+	cancel()
+	<-done
+	if err != nil || ch != 40 {
+		t.Fatalf("Rediscover: %d, %v", ch, err)
+	}
+	if b.Driver.(*emu.Chip).CE() || b.Config()&nrf.PrimRx == 0 {
+		t.Error("not in PRX mode with CE low")
+	}
+	if b.FIFO()&nrf.RxEmpty != 0 {
+		t.Error("received packet not left in Rx FIFO")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Millisecond)
+	defer cancel()
+	if _, err := nrf.Rediscover(ctx, b, []int{10}, time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("error %v, want DeadlineExceeded", err)
+	}
+}
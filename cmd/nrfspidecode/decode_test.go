@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var testNames = names{"CSN", "SCK", "MOSI", "MISO"}
+
+// waveform returns SPI mode 0 line states of one transaction preceded and
+// followed by idle state.
+func waveform(mosi, miso []byte) []state {
+	ss := []state{{csn: true}, {}}
+	for i := range mosi {
+		for bit := 7; bit >= 0; bit-- {
+			s := state{
+				mosi: mosi[i]>>uint(bit)&1 != 0,
+				miso: miso[i]>>uint(bit)&1 != 0,
+			}
+			ss = append(ss, s)
+			s.sck = true
+			ss = append(ss, s)
+		}
+	}
+	return append(ss, state{}, state{csn: true})
+}
+
+func b2s(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
+
+func toCSV(ss []state) string {
+	var buf bytes.Buffer
+	buf.WriteString("; sigrok export\nTime,logic.MOSI,logic.SCK,logic.CSN,logic.MISO\n")
+	for i, s := range ss {
+		fmt.Fprintf(&buf, "%g,%s,%s,%s,%s\n",
+			float64(i)*1e-6, b2s(s.mosi), b2s(s.sck), b2s(s.csn), b2s(s.miso))
+	}
+	return buf.String()
+}
+
+func toVCD(ss []state, vars string) string {
+	var buf bytes.Buffer
+	buf.WriteString("$timescale 1 us $end\n$scope module logic $end\n")
+	buf.WriteString(vars)
+	buf.WriteString("$upscope $end\n$enddefinitions $end\n")
+	var prev state
+	for i, s := range ss {
+		fmt.Fprintf(&buf, "#%d", i)
+		if i == 0 || s.csn != prev.csn {
+			fmt.Fprintf(&buf, " %s!", b2s(s.csn))
+		}
+		if i == 0 || s.sck != prev.sck {
+			fmt.Fprintf(&buf, " %s\"", b2s(s.sck))
+		}
+		if i == 0 || s.mosi != prev.mosi {
+			fmt.Fprintf(&buf, " b%s #", b2s(s.mosi))
+		}
+		if i == 0 || s.miso != prev.miso {
+			fmt.Fprintf(&buf, " %s$", b2s(s.miso))
+		}
+		buf.WriteByte('\n')
+		prev = s
+	}
+	return buf.String()
+}
+
+const vcdVars = `$var wire 1 ! CSN $end
+$var wire 1 " SCK $end
+$var wire 1 # MOSI $end
+$var wire 1 $ MISO $end
+`
+
+func decode(t *testing.T, read func(d *spiDecoder) error) []*frame {
+	t.Helper()
+	var frames []*frame
+	d := &spiDecoder{emit: func(f *frame) { frames = append(frames, f) }}
+	if err := read(d); err != nil {
+		t.Fatal(err)
+	}
+	d.flush()
+	return frames
+}
+
+func TestDecode(t *testing.T) {
+	mosi := []byte{0x25, 0x05}
+	miso := []byte{0x0e, 0x00}
+	ss := waveform(mosi, miso)
+	csv := decode(t, func(d *spiDecoder) error {
+		return readCSV(strings.NewReader(toCSV(ss)), &testNames, 1, d.step)
+	})
+	vcd := decode(t, func(d *spiDecoder) error {
+		return readVCD(strings.NewReader(toVCD(ss, vcdVars)), &testNames, d.step)
+	})
+	for _, frames := range [][]*frame{csv, vcd} {
+		if len(frames) != 1 {
+			t.Fatalf("%d frames", len(frames))
+		}
+		f := frames[0]
+		if !bytes.Equal(f.mosi, mosi) || !bytes.Equal(f.miso, miso) || f.bits != 0 {
+			t.Errorf("frame: %x %x %d", f.mosi, f.miso, f.bits)
+		}
+		if f.t != 1e-6 {
+			t.Errorf("frame time: %g", f.t)
+		}
+		if v := check(f); len(v) != 0 {
+			t.Errorf("violations: %v", v)
+		}
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	step := func(float64, state) { t.Error("step called") }
+	hdr := "Time,CSN,SCK,MOSI\n0,1,0,0,0\n"
+	if err := readCSV(strings.NewReader(hdr), &testNames, 1, step); err == nil ||
+		!strings.Contains(err.Error(), "MISO") {
+		t.Errorf("CSV without MISO: %v", err)
+	}
+	vars := strings.Replace(vcdVars, "$var wire 1 $ MISO $end\n", "", 1)
+	vcd := toVCD(waveform([]byte{0xff}, []byte{0x0e}), vars)
+	if err := readVCD(strings.NewReader(vcd), &testNames, step); err == nil ||
+		!strings.Contains(err.Error(), "MISO") {
+		t.Errorf("VCD without MISO: %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		f    frame
+		want string
+	}{
+		{frame{mosi: []byte{0x20, 0x80}, miso: []byte{0x0e, 0}}, "reserved bits"},
+		{frame{mosi: []byte{0x27}, miso: []byte{0x0e}}, "W_REGISTER without data"},
+		{frame{mosi: []byte{0x28, 0}, miso: []byte{0x0e, 0}}, "read-only"},
+		{frame{mosi: []byte{0xa0, 1}, miso: []byte{0x0f, 0}}, "full Tx FIFO"},
+		{frame{mosi: []byte{0x61, 0}, miso: []byte{0x0e, 0}}, "empty Rx FIFO"},
+		{frame{mosi: []byte{0x60, 0}, miso: []byte{0x00, 33}}, "must be flushed"},
+		{frame{mosi: []byte{0x50, 0x11}, miso: []byte{0x0e, 0}}, "ACTIVATE"},
+		{frame{mosi: []byte{0xff}, miso: []byte{0x0e}, bits: 3}, "incomplete byte"},
+	}
+	for _, tc := range tests {
+		v := check(&tc.f)
+		if len(v) != 1 || !strings.Contains(v[0], tc.want) {
+			t.Errorf("%x: %q, want %q", tc.f.mosi, v, tc.want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// names contains names of CSN, SCK, MOSI, MISO channels.
+type names [4]string
+
+func (n *names) index(name string) int {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if i := strings.LastIndexAny(name, ". "); i >= 0 {
+		name = name[i+1:] // Remove VCD scope or PulseView prefix.
+	}
+	for i, s := range n {
+		if strings.ToLower(s) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func set(s *state, i int, v bool) {
+	switch i {
+	case 0:
+		s.csn = v
+	case 1:
+		s.sck = v
+	case 2:
+		s.mosi = v
+	case 3:
+		s.miso = v
+	}
+}
+
+// readCSV reads sigrok/PulseView CSV export. Lines that start with ';' are
+// comments. First record must contain channel names. Column which name starts
+// with "time" contains sample time in seconds, otherwise sample number divided
+// by rate is used.
+func readCSV(r io.Reader, n *names, rate float64, step func(t float64, s state)) error {
+	cr := csv.NewReader(r)
+	cr.Comment = ';'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	hdr, err := cr.Read()
+	if err != nil {
+		return err
+	}
+	cols := [4]int{-1, -1, -1, -1}
+	tcol := -1
+	for i, h := range hdr {
+		if strings.HasPrefix(strings.ToLower(h), "time") {
+			tcol = i
+		} else if k := n.index(h); k >= 0 {
+			cols[k] = i
+		}
+	}
+	for k, c := range cols {
+		if c < 0 {
+			return fmt.Errorf("csv: no %s column in header %q", n[k], hdr)
+		}
+	}
+	for num := 0; ; num++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		t := float64(num) / rate
+		if tcol >= 0 && tcol < len(rec) {
+			if t, err = strconv.ParseFloat(rec[tcol], 64); err != nil {
+				return fmt.Errorf("csv: line %d: %v", num+2, err)
+			}
+		}
+		var s state
+		for k, c := range cols {
+			if c >= len(rec) {
+				return fmt.Errorf("csv: line %d: too few fields", num+2)
+			}
+			v, err := strconv.ParseFloat(rec[c], 64)
+			if err != nil {
+				return fmt.Errorf("csv: line %d: %v", num+2, err)
+			}
+			set(&s, k, v > 0.5)
+		}
+		step(t, s)
+	}
+}
+
+var timeUnits = map[string]float64{
+	"s": 1, "ms": 1e-3, "us": 1e-6, "ns": 1e-9, "ps": 1e-12, "fs": 1e-15,
+}
+
+// readVCD reads Value Change Dump file.
+func readVCD(r io.Reader, n *names, step func(t float64, s state)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	sc.Split(bufio.ScanWords)
+	next := func() string {
+		if sc.Scan() {
+			return sc.Text()
+		}
+		return "$end"
+	}
+	ids := make(map[string]int)
+	scale := 1e-9
+	var (
+		s       state
+		t       float64
+		started bool
+		checked bool
+	)
+	// check reports missing signals once, before first value change is
+	// decoded.
+	check := func() error {
+		if checked {
+			return nil
+		}
+		checked = true
+		var found [len(n)]bool
+		for _, k := range ids {
+			found[k] = true
+		}
+		for k, ok := range found {
+			if !ok {
+				return fmt.Errorf("vcd: no %s signal in definitions", n[k])
+			}
+		}
+		return nil
+	}
+	for sc.Scan() {
+		tok := sc.Text()
+		switch {
+		case tok == "$timescale":
+			var spec string
+			for w := next(); w != "$end"; w = next() {
+				spec += w
+			}
+			i := strings.IndexFunc(spec, func(r rune) bool { return r < '0' || r > '9' })
+			if i <= 0 {
+				return fmt.Errorf("vcd: bad timescale %q", spec)
+			}
+			num, _ := strconv.Atoi(spec[:i])
+			unit, ok := timeUnits[spec[i:]]
+			if !ok {
+				return fmt.Errorf("vcd: bad timescale unit %q", spec[i:])
+			}
+			scale = float64(num) * unit
+		case tok == "$var":
+			var f []string
+			for w := next(); w != "$end"; w = next() {
+				f = append(f, w)
+			}
+			// $var type size id name [range] $end
+			if len(f) >= 4 {
+				if k := n.index(f[3]); k >= 0 {
+					ids[f[2]] = k
+				}
+			}
+		case tok == "$enddefinitions":
+			for w := next(); w != "$end"; w = next() {
+			}
+			if err := check(); err != nil {
+				return err
+			}
+		case tok == "$dumpvars" || tok == "$end":
+			// Values inside $dumpvars are handled as normal changes.
+		case strings.HasPrefix(tok, "$"):
+			for w := next(); w != "$end"; w = next() {
+			}
+		case tok[0] == '#':
+			v, err := strconv.ParseFloat(tok[1:], 64)
+			if err != nil {
+				return fmt.Errorf("vcd: bad time %q", tok)
+			}
+			if err := check(); err != nil {
+				return err
+			}
+			if started {
+				step(t, s)
+			}
+			t, started = v*scale, true
+		case tok[0] == 'b' || tok[0] == 'B':
+			id := next()
+			if k, ok := ids[id]; ok {
+				set(&s, k, strings.HasSuffix(tok, "1"))
+			}
+		default:
+			if k, ok := ids[tok[1:]]; ok {
+				set(&s, k, tok[0] == '1')
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if err := check(); err != nil {
+		return err
+	}
+	if started {
+		step(t, s)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+// nrfspidecode decodes nRF24L01(+) SPI transactions captured by logic
+// analyzer and exported by sigrok/PulseView as CSV or VCD file. It prints one
+// line per transaction (framed by CSN) and flags protocol violations.
+//
+// Usage:
+//
+//	nrfspidecode [flags] [file]
+//
+// File is read from standard input if not specified. VCD format is assumed if
+// file name ends with ".vcd" (see -format flag).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ziutek/nrf"
+)
+
+func die(a ...interface{}) {
+	fmt.Fprintln(os.Stderr, a...)
+	os.Exit(1)
+}
+
+// check returns protocol violations found in f.
+func check(f *frame) []string {
+	var v []string
+	if f.bits != 0 {
+		v = append(v, fmt.Sprintf("incomplete byte (%d bits)", f.bits))
+	}
+	if len(f.mosi) == 0 {
+		return v
+	}
+	c := f.mosi[0]
+	stat := nrf.Status(f.miso[0])
+	data := f.mosi[1:]
+	switch {
+	case c < 0x20:
+		if c >= 0x1e {
+			v = append(v, "read of reserved register")
+		}
+	case c < 0x40:
+		addr := c & 0x1f
+		mask := nrf.WriteMask(addr)
+		switch {
+		case mask == 0:
+			v = append(v, "write to read-only or reserved register "+nrf.RegName(addr))
+		case len(data) == 0:
+			v = append(v, "W_REGISTER without data")
+		case addr == 0x0a || addr == 0x0b || addr == 0x10:
+			if len(data) > 5 {
+				v = append(v, fmt.Sprintf("address longer than 5 bytes (%d)", len(data)))
+			}
+		default:
+			if len(data) > 1 {
+				v = append(v, fmt.Sprintf("%d bytes written to one-byte register", len(data)))
+			}
+			if r := data[0] &^ mask; r != 0 {
+				v = append(v, fmt.Sprintf("write to reserved bits %#02x of %s", r, nrf.RegName(addr)))
+			}
+		}
+	case c == 0xa0 || c == 0xb0 || c >= 0xa8 && c <= 0xad:
+		if len(data) == 0 {
+			v = append(v, "empty payload")
+		} else if len(data) > 32 {
+			v = append(v, fmt.Sprintf("payload longer than 32 bytes (%d)", len(data)))
+		}
+		if stat&nrf.FullTx != 0 {
+			v = append(v, "payload written to full Tx FIFO")
+		}
+	case c == 0x61:
+		if stat.RxPipe() < 0 {
+			v = append(v, "R_RX_PAYLOAD with empty Rx FIFO")
+		}
+		if len(data) > 32 {
+			v = append(v, fmt.Sprintf("payload longer than 32 bytes (%d)", len(data)))
+		}
+	case c == 0x60:
+		if len(f.miso) > 1 && f.miso[1] > 32 {
+			v = append(v, "R_RX_PL_WID > 32: Rx FIFO must be flushed")
+		}
+	case c == 0x50:
+		if len(data) != 1 || data[0] != 0x73 && data[0] != 0x53 {
+			v = append(v, "unknown ACTIVATE data")
+		}
+	case c == 0xe1 || c == 0xe2 || c == 0xe3 || c == 0xff:
+	default:
+		v = append(v, "unknown command")
+	}
+	return v
+}
+
+func main() {
+	var n names
+	flag.StringVar(&n[0], "csn", "CSN", "name of CSN channel")
+	flag.StringVar(&n[1], "sck", "SCK", "name of SCK channel")
+	flag.StringVar(&n[2], "mosi", "MOSI", "name of MOSI channel")
+	flag.StringVar(&n[3], "miso", "MISO", "name of MISO channel")
+	format := flag.String("format", "", "input format: csv or vcd")
+	rate := flag.Float64("rate", 1, "CSV sample rate [Hz] if there is no time column")
+	flag.Parse()
+
+	var (
+		r    io.Reader = os.Stdin
+		name string
+	)
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		name = flag.Arg(0)
+		f, err := os.Open(name)
+		if err != nil {
+			die(err)
+		}
+		defer f.Close()
+		r = f
+	default:
+		die("Usage: nrfspidecode [flags] [file]")
+	}
+	if *format == "" {
+		*format = "csv"
+		if strings.HasSuffix(strings.ToLower(name), ".vcd") {
+			*format = "vcd"
+		}
+	}
+
+	var frames, violations int
+	d := &spiDecoder{emit: func(f *frame) {
+		frames++
+		if len(f.mosi) == 0 {
+			fmt.Printf("%.6f  empty transaction\n", f.t)
+		} else {
+			fmt.Printf("%.6f  %s\n", f.t, nrf.Decode(f.mosi, f.miso))
+		}
+		for _, v := range check(f) {
+			violations++
+			fmt.Printf("          !! %s\n", v)
+		}
+	}}
+	var err error
+	switch *format {
+	case "csv":
+		err = readCSV(r, &n, *rate, d.step)
+	case "vcd":
+		err = readVCD(r, &n, d.step)
+	default:
+		die("unknown format:", *format)
+	}
+	d.flush()
+	if err != nil {
+		die(err)
+	}
+	fmt.Fprintf(os.Stderr, "%d transactions, %d violations\n", frames, violations)
+}
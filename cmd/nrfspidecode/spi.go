@@ -0,0 +1,80 @@
+package main
+
+// state contains levels of SPI lines at some point in time.
+type state struct {
+	csn, sck, mosi, miso bool
+}
+
+// frame is SPI transaction framed by CSN.
+type frame struct {
+	t          float64 // Time of CSN falling edge [s].
+	mosi, miso []byte
+	bits       int // Number of bits in incomplete last byte.
+}
+
+// spiDecoder decodes SPI mode 0 (CPOL=0, CPHA=0, MSB first) transactions:
+// MOSI and MISO are sampled on rising edge of SCK while CSN is low.
+type spiDecoder struct {
+	prev   state
+	inited bool
+	cur    *frame
+	mo, mi byte
+	emit   func(f *frame)
+}
+
+func (d *spiDecoder) step(t float64, s state) {
+	if !d.inited {
+		d.inited = true
+		d.prev = s
+		if !s.csn {
+			d.begin(t)
+		}
+		return
+	}
+	p := d.prev
+	d.prev = s
+	switch {
+	case p.csn && !s.csn:
+		d.begin(t)
+	case !p.csn && s.csn:
+		d.end()
+	case !s.csn && !p.sck && s.sck && d.cur != nil:
+		d.sample(s)
+	}
+}
+
+func (d *spiDecoder) begin(t float64) {
+	d.cur = &frame{t: t}
+	d.mo, d.mi = 0, 0
+}
+
+func (d *spiDecoder) sample(s state) {
+	f := d.cur
+	d.mo <<= 1
+	d.mi <<= 1
+	if s.mosi {
+		d.mo |= 1
+	}
+	if s.miso {
+		d.mi |= 1
+	}
+	f.bits++
+	if f.bits == 8 {
+		f.mosi = append(f.mosi, d.mo)
+		f.miso = append(f.miso, d.mi)
+		f.bits = 0
+		d.mo, d.mi = 0, 0
+	}
+}
+
+func (d *spiDecoder) end() {
+	if d.cur != nil {
+		d.emit(d.cur)
+		d.cur = nil
+	}
+}
+
+// flush emits unterminated frame.
+func (d *spiDecoder) flush() {
+	d.end()
+}
@@ -1,13 +1,32 @@
 package nrf
 
 // Reg invokes R_REGISTER command.
+func (r Radio) Reg(addr byte, val []byte) (Status, error) {
+	var stat [1]byte
+	_, err := r.WriteRead([]byte{addr}, stat[:], nil, val)
+	return Status(stat[0]), err
+}
+
+// Reg invokes R_REGISTER command. If d.Cache contains value of register it is
+// used instead and d.Status isn't updated.
 func (d *Device) Reg(addr byte, val []byte) {
 	if d.Err != nil {
 		return
 	}
+	if d.Cache != nil && d.Cache.get(addr, val) {
+		return
+	}
+	d.Status, d.Err = d.radio().Reg(addr, val)
+	if d.Err == nil && d.Cache != nil {
+		d.Cache.put(addr, val)
+	}
+}
+
+// SetReg invokes W_REGISTER command.
+func (r Radio) SetReg(addr byte, val ...byte) (Status, error) {
 	var stat [1]byte
-	_, d.Err = d.WriteRead([]byte{addr}, stat[:], nil, val)
-	d.Status = Status(stat[0])
+	_, err := r.WriteRead([]byte{addr | 0x20}, stat[:], val)
+	return Status(stat[0]), err
 }
 
 // SetReg invokes W_REGISTER command.
@@ -15,9 +34,24 @@ func (d *Device) SetReg(addr byte, val ...byte) {
 	if d.Err != nil {
 		return
 	}
+	d.Status, d.Err = d.radio().SetReg(addr, val...)
+	if d.Err == nil && d.Cache != nil {
+		d.Cache.put(addr, val)
+	}
+}
+
+// cmd invokes one byte command that returns only STATUS.
+func (r Radio) cmd(c byte) (Status, error) {
+	var stat [1]byte
+	_, err := r.WriteRead([]byte{c}, stat[:])
+	return Status(stat[0]), err
+}
+
+// ReadRxP invokes R_RX_PAYLOAD command.
+func (r Radio) ReadRxP(pay []byte) (Status, error) {
 	var stat [1]byte
-	_, d.Err = d.WriteRead([]byte{addr | 0x20}, stat[:], val)
-	d.Status = Status(stat[0])
+	_, err := r.WriteRead([]byte{0x61}, stat[:], nil, pay)
+	return Status(stat[0]), err
 }
 
 // ReadRxP invokes R_RX_PAYLOAD command.
@@ -25,26 +59,37 @@ func (d *Device) ReadRxP(pay []byte) {
 	if d.Err != nil {
 		return
 	}
-	var stat [1]byte
-	_, d.Err = d.WriteRead([]byte{0x61}, stat[:], nil, pay)
-	d.Status = Status(stat[0])
+	d.Status, d.Err = d.radio().ReadRxP(pay)
 }
 
-func checkPlen(plen int) {
+func checkPlen(plen int) error {
 	if plen > 32 {
-		panic("plen>32")
+		return argErr(ErrPayloadTooLong, plen)
+	}
+	return nil
+}
+
+// WriteTxP invokes W_TX_PAYLOAD command.
+func (r Radio) WriteTxP(pay []byte) (Status, error) {
+	if err := checkPlen(len(pay)); err != nil {
+		return 0, err
 	}
+	var stat [1]byte
+	_, err := r.WriteRead([]byte{0xa0}, stat[:], pay)
+	return Status(stat[0]), err
 }
 
 // WriteTxP invokes W_TX_PAYLOAD command.
 func (d *Device) WriteTxP(pay []byte) {
-	checkPlen(len(pay))
-	if d.Err != nil {
+	if !d.check(checkPlen(len(pay))) {
 		return
 	}
-	var stat [1]byte
-	_, d.Err = d.WriteRead([]byte{0xa0}, stat[:], pay)
-	d.Status = Status(stat[0])
+	d.Status, d.Err = d.radio().WriteTxP(pay)
+}
+
+// FlushTx invokes FLUSH_TX command.
+func (r Radio) FlushTx() (Status, error) {
+	return r.cmd(0xe1)
 }
 
 // FlushTx invokes FLUSH_TX command.
@@ -52,9 +97,12 @@ func (d *Device) FlushTx() {
 	if d.Err != nil {
 		return
 	}
-	var stat [1]byte
-	_, d.Err = d.WriteRead([]byte{0xe1}, stat[:])
-	d.Status = Status(stat[0])
+	d.Status, d.Err = d.radio().FlushTx()
+}
+
+// FlushRx invokes FLUSH_RX command.
+func (r Radio) FlushRx() (Status, error) {
+	return r.cmd(0xe2)
 }
 
 // FlushRx invokes FLUSH_RX command.
@@ -62,9 +110,12 @@ func (d *Device) FlushRx() {
 	if d.Err != nil {
 		return
 	}
-	var stat [1]byte
-	_, d.Err = d.WriteRead([]byte{0xe2}, stat[:])
-	d.Status = Status(stat[0])
+	d.Status, d.Err = d.radio().FlushRx()
+}
+
+// ReuseTxP invokes REUSE_TX_PL command.
+func (r Radio) ReuseTxP() (Status, error) {
+	return r.cmd(0xe3)
 }
 
 // ReuseTxP invokes REUSE_TX_PL command.
@@ -72,19 +123,32 @@ func (d *Device) ReuseTxP() {
 	if d.Err != nil {
 		return
 	}
-	var stat [1]byte
-	_, d.Err = d.WriteRead([]byte{0xe3}, stat[:])
-	d.Status = Status(stat[0])
+	d.Status, d.Err = d.radio().ReuseTxP()
 }
 
 // Activate invokes nRF24L01 ACTIVATE command.
+func (r Radio) Activate(b byte) (Status, error) {
+	var stat [1]byte
+	_, err := r.WriteRead([]byte{0x50, b}, stat[:])
+	return Status(stat[0]), err
+}
+
+// Activate invokes nRF24L01 ACTIVATE command. It invalidates d.Cache.
 func (d *Device) Activate(b byte) {
 	if d.Err != nil {
 		return
 	}
-	var stat [1]byte
-	_, d.Err = d.WriteRead([]byte{0x50, b}, stat[:])
-	d.Status = Status(stat[0])
+	d.Status, d.Err = d.radio().Activate(b)
+	if d.Cache != nil {
+		d.Cache.Invalidate()
+	}
+}
+
+// RxPLen invokes R_RX_PL_WID command.
+func (r Radio) RxPLen() (int, Status, error) {
+	var ret [2]byte
+	_, err := r.WriteRead([]byte{0x60}, ret[:])
+	return int(ret[1]), Status(ret[0]), err
 }
 
 // RxPLen invokes R_RX_PL_WID command.
@@ -92,33 +156,53 @@ func (d *Device) RxPLen() int {
 	if d.Err != nil {
 		return 0
 	}
-	var ret [2]byte
-	_, d.Err = d.WriteRead([]byte{0x60}, ret[:])
-	d.Status = Status(ret[0])
-	return int(ret[1])
+	var plen int
+	plen, d.Status, d.Err = d.radio().RxPLen()
+	return plen
+}
+
+// WriteAckP invokes W_ACK_PAYLOAD command.
+func (r Radio) WriteAckP(pn int, pay []byte) (Status, error) {
+	if err := checkPN(pn); err != nil {
+		return 0, err
+	}
+	if err := checkPlen(len(pay)); err != nil {
+		return 0, err
+	}
+	var stat [1]byte
+	_, err := r.WriteRead([]byte{byte(0xa8 | pn)}, stat[:], pay)
+	return Status(stat[0]), err
 }
 
 // WriteAckP invokes W_ACK_PAYLOAD command.
 func (d *Device) WriteAckP(pn int, pay []byte) {
-	checkPN(pn)
-	checkPlen(len(pay))
-	if d.Err != nil {
+	if !d.check(checkPN(pn)) || !d.check(checkPlen(len(pay))) {
 		return
 	}
+	d.Status, d.Err = d.radio().WriteAckP(pn, pay)
+}
+
+// WriteTxPNoAck invokes W_TX_PAYLOAD_NOACK command.
+func (r Radio) WriteTxPNoAck(pay []byte) (Status, error) {
+	if err := checkPlen(len(pay)); err != nil {
+		return 0, err
+	}
 	var stat [1]byte
-	_, d.Err = d.WriteRead([]byte{byte(0xa8 | pn)}, stat[:], pay)
-	d.Status = Status(stat[0])
+	_, err := r.WriteRead([]byte{0xb0}, stat[:], pay)
+	return Status(stat[0]), err
 }
 
 // WriteTxPNoAck invokes W_TX_PAYLOAD_NOACK command.
 func (d *Device) WriteTxPNoAck(pay []byte) {
-	checkPlen(len(pay))
-	if d.Err != nil {
+	if !d.check(checkPlen(len(pay))) {
 		return
 	}
-	var stat [1]byte
-	_, d.Err = d.WriteRead([]byte{0xa0}, stat[:], pay)
-	d.Status = Status(stat[0])
+	d.Status, d.Err = d.radio().WriteTxPNoAck(pay)
+}
+
+// NOP invokes NOP command.
+func (r Radio) NOP() (Status, error) {
+	return r.cmd(0xff)
 }
 
 // NOP invokes NOP command.
@@ -126,7 +210,5 @@ func (d *Device) NOP() {
 	if d.Err != nil {
 		return
 	}
-	var stat [1]byte
-	_, d.Err = d.WriteRead([]byte{0xff}, stat[:])
-	d.Status = Status(stat[0])
+	d.Status, d.Err = d.radio().NOP()
 }
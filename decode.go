@@ -0,0 +1,126 @@
+package nrf
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// WriteMask returns writable bits of register at addr (0xff for every byte
+// of address registers). It returns 0 for read-only and reserved registers.
+func WriteMask(addr byte) byte {
+	if int(addr) < len(writeMask) {
+		return writeMask[addr]
+	}
+	return 0
+}
+
+// RegName returns datasheet name of register at addr.
+func RegName(addr byte) string {
+	if int(addr) < len(regs) {
+		return regs[addr].name
+	}
+	return fmt.Sprintf("REG_%02X", addr)
+}
+
+// FormatReg returns human readable value of register at addr.
+func FormatReg(addr byte, val []byte) string {
+	if len(val) == 0 {
+		return ""
+	}
+	if int(addr) < len(regs) {
+		return regs[addr].fmt(val)
+	}
+	return hex.EncodeToString(val)
+}
+
+// CmdName returns datasheet name of SPI command c.
+func CmdName(c byte) string {
+	switch {
+	case c < 0x20:
+		return "R_REGISTER"
+	case c < 0x40:
+		return "W_REGISTER"
+	case c >= 0xa8 && c <= 0xad:
+		return "W_ACK_PAYLOAD"
+	}
+	switch c {
+	case 0x50:
+		return "ACTIVATE"
+	case 0x60:
+		return "R_RX_PL_WID"
+	case 0x61:
+		return "R_RX_PAYLOAD"
+	case 0xa0:
+		return "W_TX_PAYLOAD"
+	case 0xb0:
+		return "W_TX_PAYLOAD_NOACK"
+	case 0xe1:
+		return "FLUSH_TX"
+	case 0xe2:
+		return "FLUSH_RX"
+	case 0xe3:
+		return "REUSE_TX_PL"
+	case 0xff:
+		return "NOP"
+	}
+	return fmt.Sprintf("CMD_%02X", c)
+}
+
+// Decode returns human readable description of SPI transaction: bytes sent to
+// chip (mosi) and received from it (miso). Missing miso bytes are decoded as
+// zeros.
+func Decode(mosi, miso []byte) string {
+	if len(mosi) == 0 {
+		return "empty transaction"
+	}
+	if len(miso) < len(mosi) {
+		miso = append(miso[:len(miso):len(miso)], make([]byte, len(mosi)-len(miso))...)
+	}
+	c := mosi[0]
+	s := CmdName(c)
+	out, in := mosi[1:], miso[1:]
+	switch {
+	case c < 0x20:
+		s += " " + RegName(c) + " -> " + FormatReg(c, in)
+	case c < 0x40:
+		s += " " + RegName(c&0x1f) + " <- " + FormatReg(c&0x1f, out)
+	case c >= 0xa8 && c <= 0xad:
+		s += fmt.Sprintf(" P%d <- %x", c&7, out)
+	case c == 0x50:
+		s += fmt.Sprintf(" %x", out)
+	case c == 0x60:
+		if len(in) > 0 {
+			s += fmt.Sprintf(" -> %d", in[0])
+		}
+	case c == 0x61:
+		s += fmt.Sprintf(" -> %x", in)
+	case c == 0xa0 || c == 0xb0:
+		s += fmt.Sprintf(" <- %x", out)
+	default:
+		if len(out) > 0 {
+			s += fmt.Sprintf(" %x", out)
+		}
+	}
+	return s + " (STATUS " + Status(miso[0]).String() + ")"
+}
+
+// Flatten returns bytes sent and received by Driver.WriteRead(oi...): out
+// and in slices of every pair padded with zeros to the same length.
+func Flatten(oi [][]byte) (mosi, miso []byte) {
+	for i := 0; i < len(oi); i += 2 {
+		out := oi[i]
+		var in []byte
+		if i+1 < len(oi) {
+			in = oi[i+1]
+		}
+		n := len(out)
+		if len(in) > n {
+			n = len(in)
+		}
+		mosi = append(mosi, out...)
+		mosi = append(mosi, make([]byte, n-len(out))...)
+		miso = append(miso, in...)
+		miso = append(miso, make([]byte, n-len(in))...)
+	}
+	return
+}
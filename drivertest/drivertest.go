@@ -0,0 +1,334 @@
+// Package drivertest provides conformance tests for nrf.Driver
+// implementations. Tests are run from go test against driver connected to real
+// nRF24L01+ chip or to emulated one:
+//
+//	func TestDriver(t *testing.T) {
+//		drivertest.Run(t, emu.NewChip())
+//	}
+//
+// Tests change configuration of the chip (they transmit some packets on
+// channel 2 with -18 dBm output power, without waiting for ACK) and leave it
+// powered down. Single checks can be run using WriteRead, CSN, CE and IRQ.
+package drivertest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ziutek/nrf"
+)
+
+// PulseReporter can be implemented by Driver that can measure width of CE
+// pulse generated by SetCE(2). Emulated drivers, that have no physical CE line,
+// may report nominal width, so CE checks the reported width only against
+// MinPulse: a real pulse is verified by transmission it starts.
+type PulseReporter interface {
+	// LastPulse returns width of last CE pulse.
+	LastPulse() time.Duration
+}
+
+// MinPulse is minimum width of CE pulse that starts transmission (Thce).
+const MinPulse = 10 * time.Microsecond
+
+// Run runs all conformance tests of drv as subtests of t.
+func Run(t *testing.T, drv nrf.Driver) {
+	if !t.Run("Setup", func(t *testing.T) { setup(t, drv) }) {
+		return
+	}
+	t.Run("WriteRead", func(t *testing.T) { WriteRead(t, drv) })
+	t.Run("CSN", func(t *testing.T) { CSN(t, drv) })
+	t.Run("CE", func(t *testing.T) { CE(t, drv) })
+	if w, ok := drv.(nrf.IRQWaiter); ok {
+		t.Run("IRQ", func(t *testing.T) { IRQ(t, drv, w) })
+	}
+	d := &nrf.Device{Driver: drv}
+	d.SetCE(0)
+	d.SetCfg(0)
+	if d.Err != nil {
+		t.Error("power down:", d.Err)
+	}
+}
+
+// setup configures chip to be in PTX mode without auto acknowledgement.
+func setup(t *testing.T, drv nrf.Driver) {
+	d := &nrf.Device{Driver: drv}
+	d.SetCE(0)
+	d.SetCfg(0)
+	d.SetFeature(0)
+	d.SetDynPD(0)
+	d.SetAA(0)
+	d.SetALen(5)
+	d.SetCh(2)
+	d.SetRF(nrf.Pwr(-18))
+	d.FlushTx()
+	d.FlushRx()
+	d.Clear(nrf.RxDR | nrf.TxDS | nrf.MaxRT)
+	d.SetCfg(nrf.EnCRC | nrf.PwrUp)
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	time.Sleep(2 * time.Millisecond) // Tpd2stby
+	// Use one out/in pair (multi-slice WriteRead is tested later).
+	in := make([]byte, 2)
+	writeRead(t, drv, []byte{0x05, 0}, in)
+	if in[1] != 2 {
+		t.Fatalf("RF_CH = %d after write of 2: chip not connected?", in[1])
+	}
+}
+
+func writeRead(t *testing.T, drv nrf.Driver, oi ...[]byte) {
+	t.Helper()
+	if _, err := drv.WriteRead(oi...); err != nil {
+		t.Fatal("WriteRead:", err)
+	}
+}
+
+// status returns STATUS read by NOP command.
+func status(t *testing.T, drv nrf.Driver) byte {
+	t.Helper()
+	var stat [1]byte
+	writeRead(t, drv, []byte{0xff}, stat[:])
+	return stat[0]
+}
+
+// txAddr returns TX_ADDR read by single out/in pair.
+func txAddr(t *testing.T, drv nrf.Driver) []byte {
+	t.Helper()
+	in := make([]byte, 6)
+	writeRead(t, drv, []byte{0x10, 0, 0, 0, 0, 0}, in)
+	return in[1:]
+}
+
+// WriteRead checks multi-slice semantics of WriteRead: every out/in pair
+// transfers max(len(out), len(in)) bytes, out is sent and in is filled with
+// bytes received at the same time, all pairs are transfered in one
+// transaction.
+func WriteRead(t *testing.T, drv nrf.Driver) {
+	t.Run("Status", func(t *testing.T) {
+		s := status(t, drv)
+		var stat, reg [1]byte
+		writeRead(t, drv, []byte{0x07}, stat[:], nil, reg[:])
+		if stat[0] != s || reg[0] != s {
+			t.Errorf("STATUS: NOP -> %#02x, R_REGISTER -> %#02x, %#02x", s, stat[0], reg[0])
+		}
+	})
+	t.Run("Pairs", func(t *testing.T) {
+		var stat [1]byte
+		writeRead(t, drv, []byte{0x30}, stat[:], []byte{1, 2}, nil, []byte{3, 4, 5})
+		if stat[0] != status(t, drv) {
+			t.Errorf("STATUS captured in first pair: %#02x", stat[0])
+		}
+		if a := txAddr(t, drv); !bytes.Equal(a, []byte{1, 2, 3, 4, 5}) {
+			t.Errorf("TX_ADDR written by three pairs: %x, want 0102030405", a)
+		}
+	})
+	t.Run("NilOut", func(t *testing.T) {
+		writeRead(t, drv, []byte{0x30, 6, 7, 8, 9, 10})
+		var stat [1]byte
+		addr := make([]byte, 5)
+		writeRead(t, drv, []byte{0x10}, stat[:], nil, addr)
+		if !bytes.Equal(addr, []byte{6, 7, 8, 9, 10}) {
+			t.Errorf("TX_ADDR read by nil out: %x, want 060708090a", addr)
+		}
+	})
+	t.Run("NilIn", func(t *testing.T) {
+		writeRead(t, drv, []byte{0x30}, nil, []byte{11, 12, 13, 14, 15}, nil)
+		if a := txAddr(t, drv); !bytes.Equal(a, []byte{11, 12, 13, 14, 15}) {
+			t.Errorf("TX_ADDR written with nil in: %x, want 0b0c0d0e0f", a)
+		}
+	})
+	t.Run("FullDuplex", func(t *testing.T) {
+		// MOSI bytes after R_REGISTER command are ignored by chip.
+		in := make([]byte, 6)
+		writeRead(t, drv, []byte{0x10, 0xff, 0xff, 0xff, 0xff, 0xff}, in)
+		if !bytes.Equal(in[1:], []byte{11, 12, 13, 14, 15}) {
+			t.Errorf("TX_ADDR read with len(out) == len(in): %x", in[1:])
+		}
+		// in longer than out: out must be padded.
+		in = make([]byte, 6)
+		writeRead(t, drv, []byte{0x10}, in)
+		if !bytes.Equal(in[1:], []byte{11, 12, 13, 14, 15}) {
+			t.Errorf("TX_ADDR read with len(out) < len(in): %x", in[1:])
+		}
+		// out longer than in: all out bytes must be sent.
+		var stat [1]byte
+		writeRead(t, drv, []byte{0x30, 16, 17, 18, 19, 20}, stat[:])
+		if a := txAddr(t, drv); !bytes.Equal(a, []byte{16, 17, 18, 19, 20}) {
+			t.Errorf("TX_ADDR written with len(out) > len(in): %x", a)
+		}
+	})
+}
+
+// CSN checks that every WriteRead call (and every transaction of
+// WriteReadBatch if drv implements nrf.Batcher) is framed by CSN: command
+// sent in next transaction isn't treated as data of previous one.
+func CSN(t *testing.T, drv nrf.Driver) {
+	in := make([]byte, 6)
+	// If CSN isn't raised, R_REGISTER ignores next W_REGISTER command.
+	writeRead(t, drv, []byte{0x10}, in)
+	writeRead(t, drv, []byte{0x30, 21, 22, 23, 24, 25})
+	if a := txAddr(t, drv); !bytes.Equal(a, []byte{21, 22, 23, 24, 25}) {
+		t.Errorf("TX_ADDR written after R_REGISTER: %x: CSN not raised?", a)
+	}
+	b, ok := drv.(nrf.Batcher)
+	if !ok {
+		return
+	}
+	stat := make([]byte, 1)
+	err := b.WriteReadBatch(
+		[][]byte{{0x10}, in},
+		[][]byte{{0x30, 26, 27, 28, 29, 30}, stat},
+		[][]byte{{0xff}, stat},
+	)
+	if err != nil {
+		t.Fatal("WriteReadBatch:", err)
+	}
+	if !bytes.Equal(in[1:], []byte{21, 22, 23, 24, 25}) {
+		t.Errorf("TX_ADDR read in batch: %x, want 1516171819", in[1:])
+	}
+	if a := txAddr(t, drv); !bytes.Equal(a, []byte{26, 27, 28, 29, 30}) {
+		t.Errorf("TX_ADDR written in batch: %x: CSN not raised?", a)
+	}
+}
+
+// waitFor polls STATUS and FIFO_STATUS until cond returns true or timeout
+// elapses.
+func waitFor(d *nrf.Device, timeout time.Duration, cond func(nrf.Status, nrf.FIFO) bool) bool {
+	end := time.Now().Add(timeout)
+	for {
+		f := d.FIFO()
+		if d.Err != nil {
+			return false
+		}
+		if cond(d.Status, f) {
+			return true
+		}
+		if time.Now().After(end) {
+			return false
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+}
+
+func sent(s nrf.Status, f nrf.FIFO) bool {
+	return s&nrf.TxDS != 0
+}
+
+func empty(s nrf.Status, f nrf.FIFO) bool {
+	return f&nrf.TxEmpty != 0
+}
+
+const timeout = 20 * time.Millisecond
+
+// CE checks that SetCE follows the contract documented on nrf.Driver.
+// CE line is observed using transmissions started by it.
+func CE(t *testing.T, drv nrf.Driver) {
+	d := &nrf.Device{Driver: drv}
+	pay := make([]byte, 32)
+	check := func(what string) {
+		t.Helper()
+		if d.Err != nil {
+			t.Fatal(what+":", d.Err)
+		}
+	}
+	d.SetCE(0)
+	d.FlushTx()
+	d.Clear(nrf.TxDS | nrf.MaxRT)
+	d.WriteTxP(pay)
+	d.WriteTxP(pay)
+	check("SetCE(0)")
+	if waitFor(d, 2*time.Millisecond, sent) {
+		t.Fatal("SetCE(0): payload sent with CE low")
+	}
+
+	// Pulse should send only one payload.
+	start := time.Now()
+	d.SetCE(2)
+	dur := time.Since(start)
+	check("SetCE(2)")
+	if p, ok := drv.(PulseReporter); ok {
+		if w := p.LastPulse(); w < MinPulse {
+			t.Errorf("SetCE(2): pulse width %v < %v", w, MinPulse)
+		}
+	} else if dur < MinPulse {
+		t.Logf("SetCE(2) returned after %v (< %v): asynchronous driver?", dur, MinPulse)
+	}
+	if !waitFor(d, timeout, sent) {
+		t.Fatal("SetCE(2): payload not sent")
+	}
+	d.Clear(nrf.TxDS)
+	check("SetCE(2)")
+	if waitFor(d, 2*time.Millisecond, empty) {
+		t.Error("SetCE(2): two payloads sent: pulse too long or CE left high")
+	}
+
+	// CE high should send all payloads and next written ones.
+	d.SetCE(1)
+	check("SetCE(1)")
+	if !waitFor(d, timeout, empty) {
+		t.Fatal("SetCE(1): payload not sent")
+	}
+	d.Clear(nrf.TxDS)
+	d.WriteTxP(pay)
+	check("SetCE(1)")
+	if !waitFor(d, timeout, sent) {
+		t.Error("SetCE(1): payload written with CE high not sent")
+	}
+
+	// CE low should stop transmission.
+	d.SetCE(0)
+	d.Clear(nrf.TxDS)
+	d.WriteTxP(pay)
+	check("SetCE(0)")
+	if waitFor(d, 2*time.Millisecond, sent) {
+		t.Error("SetCE(0) after SetCE(1): payload sent with CE low")
+	}
+	d.FlushTx()
+	d.Clear(nrf.TxDS)
+	check("SetCE(0)")
+}
+
+// IRQ checks IRQWaiter implementation of drv. IRQ line is activated by
+// TxDS interrupt.
+func IRQ(t *testing.T, drv nrf.Driver, w nrf.IRQWaiter) {
+	d := &nrf.Device{Driver: drv}
+	d.SetCE(0)
+	d.FlushTx()
+	d.Clear(nrf.RxDR | nrf.TxDS | nrf.MaxRT)
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	if irq, err := w.IRQ(); err != nil || irq {
+		t.Fatalf("IRQ() = %t, %v after clearing interrupt flags", irq, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	err := w.WaitIRQ(ctx)
+	cancel()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitIRQ without interrupt returned %v", err)
+	}
+	d.WriteTxP(make([]byte, 32))
+	d.SetCE(2)
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	err = w.WaitIRQ(ctx)
+	cancel()
+	if err != nil {
+		t.Errorf("WaitIRQ after transmission returned %v", err)
+	}
+	if irq, err := w.IRQ(); err != nil || !irq {
+		t.Errorf("IRQ() = %t, %v after transmission", irq, err)
+	}
+	d.Clear(nrf.TxDS)
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	if irq, err := w.IRQ(); err != nil || irq {
+		t.Errorf("IRQ() = %t, %v after clearing TxDS", irq, err)
+	}
+}
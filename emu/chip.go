@@ -0,0 +1,526 @@
+package emu
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ziutek/nrf"
+)
+
+// Register addresses.
+const (
+	regConfig     = 0x00
+	regEnAA       = 0x01
+	regEnRxAddr   = 0x02
+	regSetupAW    = 0x03
+	regSetupRetr  = 0x04
+	regRFCh       = 0x05
+	regRFSetup    = 0x06
+	regStatus     = 0x07
+	regObserveTx  = 0x08
+	regRPD        = 0x09
+	regRxAddrP0   = 0x0a
+	regRxAddrP1   = 0x0b
+	regTxAddr     = 0x10
+	regRxPwP0     = 0x11
+	regFIFOStatus = 0x17
+	regDynPD      = 0x1c
+	regFeature    = 0x1d
+)
+
+// CONFIG, STATUS and FEATURE bits used by emulator.
+const (
+	primRx = 0x01
+	pwrUp  = 0x02
+	crco   = 0x04
+	enCRC  = 0x08
+	maxRT  = 0x10
+	txDS   = 0x20
+	rxDR   = 0x40
+	irqAll = rxDR | txDS | maxRT
+	dynAck = 0x01
+	ackPay = 0x02
+	dpl    = 0x04
+)
+
+// resetRegs contains values of one-byte registers after power on reset.
+var resetRegs = [0x1e]byte{
+	regConfig:    0x08,
+	regEnAA:      0x3f,
+	regEnRxAddr:  0x03,
+	regSetupAW:   0x03,
+	regSetupRetr: 0x03,
+	regRFCh:      0x02,
+	regRFSetup:   0x0e,
+	0x0c:         0xc3,
+	0x0d:         0xc4,
+	0x0e:         0xc5,
+	0x0f:         0xc6,
+}
+
+// writeMask contains writable bits of one-byte registers. Registers with zero
+// mask are read-only, reserved or handled separately.
+var writeMask = [0x1e]byte{
+	regConfig:    0x7f,
+	regEnAA:      0x3f,
+	regEnRxAddr:  0x3f,
+	regSetupAW:   0x03,
+	regSetupRetr: 0xff,
+	regRFCh:      0x7f,
+	regRFSetup:   0xbe, // Bit 0 is obsolete in nRF24L01+.
+	0x0c:         0xff,
+	0x0d:         0xff,
+	0x0e:         0xff,
+	0x0f:         0xff,
+	0x11:         0x3f,
+	0x12:         0x3f,
+	0x13:         0x3f,
+	0x14:         0x3f,
+	0x15:         0x3f,
+	0x16:         0x3f,
+	regDynPD:     0x3f,
+	regFeature:   0x07,
+}
+
+// Mode represents operational mode of nRF24L01+. It is the same type as
+// nrf.Mode so emulated mode can be compared with nrf.ModeManager.Mode.
+type Mode = nrf.Mode
+
+const (
+	PowerDown = nrf.PowerDown
+	StandbyI  = nrf.StandbyI
+	StandbyII = nrf.StandbyII
+	RX        = nrf.RX
+	TX        = nrf.TX
+)
+
+const (
+	fifoLen = 3  // Number of levels in Rx and Tx FIFO.
+	maxPlen = 32 // Maximum payload length.
+)
+
+// payload is an entry in Rx or Tx FIFO.
+type payload struct {
+	data  []byte
+	pipe  int  // Rx pipe number or pipe of ACK payload.
+	ack   bool // Written using W_ACK_PAYLOAD.
+	noack bool // Written using W_TX_PAYLOAD_NOACK.
+	pid   byte // Packet identity.
+}
+
+// ErrCE is returned by SetCE if called with value other than 0, 1 or 2.
+var ErrCE = errors.New("emu: CE value not in 0..2")
+
+// Chip emulates nRF24L01+ transceiver. It implements nrf.Driver interface.
+//
+// Chip decodes every SPI transaction passed to WriteRead and returns the same
+// MISO data that real chip would return. Radio transmission is performed
+// synchronously, in the WriteRead or SetCE call that starts it, so Chip
+// doesn't need any background goroutine. Chip created by NewChip is alone so
+// it never receives anything and never receives ACK for transmitted payload.
+// Use Ether to link several chips.
+type Chip struct {
+	mu    *sync.Mutex
+	ether *Ether
+
+	reg    [0x1e]byte    // One-byte registers.
+	rxAddr [2][5]byte    // RX_ADDR_P0, RX_ADDR_P1.
+	txAddr [5]byte       // TX_ADDR.
+	rx, tx []payload     // Rx and Tx FIFO.
+	last   payload       // Last transmitted payload.
+	reuse  bool          // TX_REUSE.
+	ce     bool          // State of CE line.
+	pid    byte          // PID of last payload written to Tx FIFO.
+	busy   bool          // Transmission is in progress.
+	wait   chan struct{} // Closed when IRQ becomes active.
+	txGen  int           // Incremented every time Tx FIFO is flushed.
+	pulse  time.Duration // Width of last CE pulse.
+
+	lastPID  [6]int    // PID of last payload received by pipe (-1: none).
+	lastData [6][]byte // Data of last payload received by pipe.
+}
+
+// NewChip returns emulated chip in its power on reset state.
+func NewChip() *Chip {
+	c := &Chip{mu: new(sync.Mutex)}
+	c.reset()
+	return c
+}
+
+// Reset performs power on reset of c.
+func (c *Chip) Reset() {
+	c.mu.Lock()
+	c.reset()
+	c.mu.Unlock()
+}
+
+func (c *Chip) reset() {
+	c.reg = resetRegs
+	c.rxAddr[0] = [5]byte{0xe7, 0xe7, 0xe7, 0xe7, 0xe7}
+	c.rxAddr[1] = [5]byte{0xc2, 0xc2, 0xc2, 0xc2, 0xc2}
+	c.txAddr = c.rxAddr[0]
+	c.rx = c.rx[:0]
+	c.tx = c.tx[:0]
+	c.last = payload{}
+	c.reuse = false
+	c.ce = false
+	c.txGen++
+	for i := range c.lastPID {
+		c.lastPID[i] = -1
+		c.lastData[i] = nil
+	}
+}
+
+// WriteRead performs SPI conversation with emulated chip. oi contains pairs of
+// out, in slices. For every pair max(len(out), len(in)) bytes are transfered:
+// out is sent (padded with zeros) and in is filled with bytes received at the
+// same time. Whole call is treated as one transaction (CSN stays low).
+func (c *Chip) WriteRead(oi ...[]byte) (n int, err error) {
+	c.mu.Lock()
+	n = c.writeRead(oi)
+	c.notify()
+	c.mu.Unlock()
+	return n, nil
+}
+
+// WriteReadBatch implements nrf.Batcher interface: it performs SPI
+// transactions in order, every one as separate WriteRead call.
+func (c *Chip) WriteReadBatch(txs ...[][]byte) error {
+	c.mu.Lock()
+	for _, oi := range txs {
+		c.writeRead(oi)
+	}
+	c.notify()
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Chip) writeRead(oi [][]byte) (n int) {
+	var mosi []byte
+	for i := 0; i < len(oi); i += 2 {
+		mosi = append(mosi, oi[i]...)
+		for k := len(oi[i]); k < pairLen(oi, i); k++ {
+			mosi = append(mosi, 0)
+		}
+	}
+	miso := c.exec(mosi)
+	for i := 0; i < len(oi); i += 2 {
+		m := pairLen(oi, i)
+		if i+1 < len(oi) {
+			copy(oi[i+1], miso[n:n+m])
+		}
+		n += m
+	}
+	return n
+}
+
+func pairLen(oi [][]byte, i int) int {
+	n := len(oi[i])
+	if i+1 < len(oi) && len(oi[i+1]) > n {
+		n = len(oi[i+1])
+	}
+	return n
+}
+
+// SetCE sets state of CE line: v==0 sets CE low, v==1 sets CE high, v==2
+// pulses CE high and leaves it low. Pulse is long enough to send one payload.
+func (c *Chip) SetCE(v int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.notify()
+	switch v {
+	case 0:
+		c.ce = false
+	case 1:
+		if !c.ce {
+			c.ce = true
+			c.run(-1)
+		}
+	case 2:
+		c.ce = true
+		c.run(1)
+		c.ce = false
+		c.pulse = thce
+	default:
+		return ErrCE
+	}
+	return nil
+}
+
+// thce is nominal width of CE pulse generated by SetCE(2).
+const thce = 10 * time.Microsecond
+
+// LastPulse returns width of last CE pulse generated by SetCE(2) or 0 if
+// there was no pulse yet. It implements drivertest.PulseReporter. Emulated
+// pulse has no real width (transmission is started synchronously by SetCE) so
+// the nominal Thce = 10 µs is reported.
+func (c *Chip) LastPulse() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pulse
+}
+
+// CE returns current state of CE line.
+func (c *Chip) CE() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ce
+}
+
+// IRQ returns true if IRQ line is active (low).
+func (c *Chip) IRQ() (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.irq(), nil
+}
+
+func (c *Chip) irq() bool {
+	return c.reg[regStatus]&^c.reg[regConfig]&irqAll != 0
+}
+
+// WaitIRQ waits until IRQ line is active or ctx is done.
+func (c *Chip) WaitIRQ(ctx context.Context) error {
+	c.mu.Lock()
+	if c.irq() {
+		c.mu.Unlock()
+		return nil
+	}
+	if c.wait == nil {
+		c.wait = make(chan struct{})
+	}
+	wait := c.wait
+	c.mu.Unlock()
+	select {
+	case <-wait:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// notify wakes up goroutines blocked in WaitIRQ if IRQ line is active.
+func (c *Chip) notify() {
+	if c.wait != nil && c.irq() {
+		close(c.wait)
+		c.wait = nil
+	}
+}
+
+// Mode returns current operational mode of c.
+func (c *Chip) Mode() Mode {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mode()
+}
+
+func (c *Chip) mode() Mode {
+	cfg := c.reg[regConfig]
+	switch {
+	case cfg&pwrUp == 0:
+		return PowerDown
+	case !c.ce:
+		return StandbyI
+	case cfg&primRx != 0:
+		return RX
+	case c.reg[regStatus]&maxRT != 0, len(c.tx) == 0 && !c.reuse:
+		return StandbyII
+	}
+	return TX
+}
+
+func (c *Chip) status() byte {
+	s := c.reg[regStatus]&irqAll | 0x0e
+	if len(c.rx) != 0 {
+		s = s&^0x0e | byte(c.rx[0].pipe<<1)
+	}
+	if len(c.tx) == fifoLen {
+		s |= 0x01
+	}
+	return s
+}
+
+func (c *Chip) fifoStatus() byte {
+	var f byte
+	if len(c.rx) == 0 {
+		f |= 0x01
+	}
+	if len(c.rx) == fifoLen {
+		f |= 0x02
+	}
+	if len(c.tx) == 0 {
+		f |= 0x10
+	}
+	if len(c.tx) == fifoLen {
+		f |= 0x20
+	}
+	if c.reuse {
+		f |= 0x40
+	}
+	return f
+}
+
+// exec executes command and returns MISO data.
+func (c *Chip) exec(mosi []byte) []byte {
+	miso := make([]byte, len(mosi))
+	if len(mosi) == 0 {
+		return miso
+	}
+	miso[0] = c.status()
+	cmd, in, out := mosi[0], mosi[1:], miso[1:]
+	switch {
+	case cmd < 0x20: // R_REGISTER
+		c.readReg(cmd, out)
+	case cmd < 0x40: // W_REGISTER
+		c.writeReg(cmd&0x1f, in)
+	case cmd == 0x61: // R_RX_PAYLOAD
+		if len(c.rx) != 0 {
+			copy(out, c.rx[0].data)
+			c.rx = append(c.rx[:0], c.rx[1:]...)
+		}
+	case cmd == 0xa0, cmd == 0xb0: // W_TX_PAYLOAD, W_TX_PAYLOAD_NOACK
+		if cmd == 0xb0 && c.reg[regFeature]&dynAck == 0 {
+			break
+		}
+		c.reuse = false
+		if c.push(payload{data: in, noack: cmd == 0xb0}) && c.ce {
+			c.run(-1)
+		}
+	case cmd == 0xe1: // FLUSH_TX
+		c.tx = c.tx[:0]
+		c.reuse = false
+		c.txGen++
+	case cmd == 0xe2: // FLUSH_RX
+		c.rx = c.rx[:0]
+	case cmd == 0xe3: // REUSE_TX_PL
+		c.reuse = true
+	case cmd == 0x50: // ACTIVATE
+		// nRF24L01+ doesn't need activation of features.
+	case cmd == 0x60: // R_RX_PL_WID
+		if len(c.rx) != 0 && len(out) != 0 {
+			out[0] = byte(len(c.rx[0].data))
+		}
+	case cmd&0xf8 == 0xa8 && cmd&7 <= 5: // W_ACK_PAYLOAD
+		if c.reg[regFeature]&ackPay != 0 {
+			c.push(payload{data: in, pipe: int(cmd & 7), ack: true})
+		}
+	}
+	return miso
+}
+
+// push writes p to Tx FIFO. It reports whether p was written.
+func (c *Chip) push(p payload) bool {
+	if len(c.tx) == fifoLen {
+		return false
+	}
+	if len(p.data) > maxPlen {
+		p.data = p.data[:maxPlen]
+	}
+	p.data = append([]byte{}, p.data...)
+	if !p.ack {
+		c.pid = (c.pid + 1) & 3
+		p.pid = c.pid
+	}
+	c.tx = append(c.tx, p)
+	return true
+}
+
+func (c *Chip) readReg(addr byte, out []byte) {
+	if len(out) == 0 {
+		return
+	}
+	switch {
+	case addr == regRxAddrP0, addr == regRxAddrP1:
+		copy(out, c.rxAddr[addr-regRxAddrP0][:])
+	case addr == regTxAddr:
+		copy(out, c.txAddr[:])
+	case addr == regStatus:
+		out[0] = c.status()
+	case addr == regFIFOStatus:
+		out[0] = c.fifoStatus()
+	case int(addr) < len(c.reg):
+		out[0] = c.reg[addr]
+	}
+}
+
+func (c *Chip) writeReg(addr byte, in []byte) {
+	if len(in) == 0 {
+		return
+	}
+	switch {
+	case addr == regRxAddrP0, addr == regRxAddrP1:
+		copy(c.rxAddr[addr-regRxAddrP0][:], in)
+	case addr == regTxAddr:
+		copy(c.txAddr[:], in)
+	case addr == regStatus:
+		c.reg[regStatus] &^= in[0] & irqAll
+		if in[0]&maxRT != 0 && c.ce {
+			c.run(-1)
+		}
+	case int(addr) < len(c.reg):
+		c.reg[addr] = c.reg[addr]&^writeMask[addr] | in[0]&writeMask[addr]
+		switch addr {
+		case regRFCh:
+			c.reg[regObserveTx] &= 0x0f // Writing RF_CH resets PLOS_CNT.
+		case regConfig:
+			if c.ce {
+				c.run(-1)
+			}
+		}
+	}
+}
+
+// run transmits up to n payloads from Tx FIFO (all if n < 0) if c is in TX
+// mode. Transmission stops at first payload that reaches the maximum number
+// of retransmits. Reused payload is sent once per call.
+func (c *Chip) run(n int) {
+	if c.busy {
+		return
+	}
+	c.busy = true
+	defer func() { c.busy = false }()
+	for ; n != 0 && c.mode() == TX; n-- {
+		reused := len(c.tx) == 0
+		if !reused {
+			c.last = c.tx[0]
+		}
+		gen := c.txGen
+		if !c.transmit(c.last) || reused {
+			return
+		}
+		if gen == c.txGen {
+			c.tx = append(c.tx[:0], c.tx[1:]...)
+		}
+	}
+}
+
+// transmit sends p and sets ARC, PLOS, MaxRT and TxDS accordingly. It reports
+// whether p was successfully sent.
+func (c *Chip) transmit(p payload) bool {
+	wantAck := !p.noack && c.reg[regEnAA]&1 != 0
+	arc := c.reg[regSetupRetr] & 0x0f
+	for n := byte(0); ; n++ {
+		c.reg[regObserveTx] = c.reg[regObserveTx]&0xf0 | n
+		// Lone chip never receives ACK.
+		acked := c.ether != nil && c.ether.send(c, p, wantAck)
+		if !wantAck || acked {
+			c.reg[regStatus] |= txDS
+			return true
+		}
+		if n == arc {
+			c.lost(arc)
+			return false
+		}
+	}
+}
+
+// lost records payload lost after arc retransmits.
+func (c *Chip) lost(arc byte) {
+	plos := c.reg[regObserveTx] >> 4
+	if plos < 15 {
+		plos++
+	}
+	c.reg[regObserveTx] = plos<<4 | arc
+	c.reg[regStatus] |= maxRT
+}
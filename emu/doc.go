@@ -0,0 +1,5 @@
+// Package emu provides software emulator of nRF24L01+ transceiver. Emulated
+// chip implements nrf.Driver interface so it can be used in place of real
+// hardware to test code that uses nrf.Device. Many emulated chips can
+// communicate with each other using virtual RF medium (Ether).
+package emu
@@ -0,0 +1,12 @@
+package emu_test
+
+import (
+	"testing"
+
+	"github.com/ziutek/nrf/drivertest"
+	"github.com/ziutek/nrf/emu"
+)
+
+func TestDriver(t *testing.T) {
+	drivertest.Run(t, emu.NewChip())
+}
@@ -0,0 +1,215 @@
+package emu
+
+import (
+	"bytes"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ziutek/nrf"
+)
+
+// Ether is virtual RF medium that links emulated chips. Payload transmitted by
+// PTX is received by every chip in RX mode that uses the same RF channel, data
+// rate, CRC length and address. Enhanced ShockBurst auto acknowledgment,
+// retransmissions, ACK payloads and PID based duplicate detection are emulated.
+type Ether struct {
+	mu      sync.Mutex
+	chips   []*Chip
+	rnd     *rand.Rand
+	loss    float64
+	latency time.Duration
+}
+
+// NewEther returns empty ether. Use its NewChip method to add chips to it.
+func NewEther() *Ether {
+	return &Ether{rnd: rand.New(rand.NewSource(1))}
+}
+
+// NewChip returns new emulated chip attached to e.
+func (e *Ether) NewChip() *Chip {
+	c := &Chip{mu: &e.mu, ether: e}
+	e.mu.Lock()
+	c.reset()
+	e.chips = append(e.chips, c)
+	e.mu.Unlock()
+	return c
+}
+
+// SetLoss sets probability of losing any packet (payload or ACK) in the air.
+func (e *Ether) SetLoss(p float64) {
+	e.mu.Lock()
+	e.loss = p
+	e.mu.Unlock()
+}
+
+// SetLatency sets time that every transmission attempt spends in the air.
+// Ether is unlocked during this time so other chips can be used.
+func (e *Ether) SetLatency(d time.Duration) {
+	e.mu.Lock()
+	e.latency = d
+	e.mu.Unlock()
+}
+
+// Seed seeds random number generator used to emulate packet loss.
+func (e *Ether) Seed(seed int64) {
+	e.mu.Lock()
+	e.rnd.Seed(seed)
+	e.mu.Unlock()
+}
+
+func (e *Ether) lose() bool {
+	return e.loss > 0 && e.rnd.Float64() < e.loss
+}
+
+// packet represents Enhanced ShockBurst packet in the air.
+type packet struct {
+	ch   byte
+	rate nrf.DataRate
+	crc  int
+	addr []byte
+	dpl  bool
+	payload
+}
+
+// send transmits p from ptx and reports whether ptx received ACK. It must be
+// called with e.mu locked.
+func (e *Ether) send(ptx *Chip, p payload, wantAck bool) (acked bool) {
+	if e.latency > 0 {
+		e.mu.Unlock()
+		time.Sleep(e.latency)
+		e.mu.Lock()
+	}
+	pkt := packet{
+		ch:      ptx.reg[regRFCh],
+		rate:    ptx.rate(),
+		crc:     ptx.crcLen(),
+		addr:    ptx.txAddr[:ptx.aw()],
+		dpl:     ptx.dynamic(0),
+		payload: p,
+	}
+	for _, prx := range e.chips {
+		if prx == ptx || e.lose() {
+			continue
+		}
+		pn, ok := prx.receive(&pkt)
+		if !ok || !wantAck || prx.reg[regEnAA]&(1<<uint(pn)) == 0 {
+			continue
+		}
+		// PRX sends ACK. Only first one can be received by PTX.
+		if !acked && !e.lose() {
+			acked = ptx.ack(prx, pn)
+		}
+		prx.notify()
+	}
+	return acked
+}
+
+func (c *Chip) aw() int {
+	return int(c.reg[regSetupAW]) + 2
+}
+
+func (c *Chip) rate() nrf.DataRate {
+	return nrf.RF(c.reg[regRFSetup]).DataRate()
+}
+
+func (c *Chip) crcLen() int {
+	cfg := c.reg[regConfig]
+	switch {
+	case cfg&enCRC == 0 && c.reg[regEnAA] == 0:
+		return 0
+	case cfg&crco != 0:
+		return 2
+	}
+	return 1
+}
+
+// dynamic reports whether dynamic payload length is enabled for pipe pn.
+func (c *Chip) dynamic(pn int) bool {
+	return c.reg[regFeature]&dpl != 0 && c.reg[regDynPD]&(1<<uint(pn)) != 0
+}
+
+// ard returns auto retransmit delay [µs].
+func (c *Chip) ard() int {
+	return (int(c.reg[regSetupRetr]>>4) + 1) * 250
+}
+
+func (c *Chip) pipeAddr(pn int) []byte {
+	if pn < 2 {
+		return c.rxAddr[pn][:c.aw()]
+	}
+	a := c.rxAddr[1]
+	a[0] = c.reg[regRxAddrP0+pn]
+	return a[:c.aw()]
+}
+
+// pipe returns number of enabled pipe that uses addr or -1.
+func (c *Chip) pipe(addr []byte) int {
+	for pn := 0; pn < 6; pn++ {
+		if c.reg[regEnRxAddr]&(1<<uint(pn)) != 0 &&
+			bytes.Equal(c.pipeAddr(pn), addr) {
+			return pn
+		}
+	}
+	return -1
+}
+
+// receive tries to receive pkt. It returns number of pipe that received pkt
+// and reports whether the PRX should acknowledge it. Duplicated payload is
+// acknowledged but not written to Rx FIFO.
+func (c *Chip) receive(pkt *packet) (int, bool) {
+	if c.mode() != RX || pkt.ch != c.reg[regRFCh] || pkt.rate != c.rate() ||
+		pkt.crc != c.crcLen() || len(pkt.addr) != c.aw() {
+		return -1, false
+	}
+	pn := c.pipe(pkt.addr)
+	if pn < 0 || pkt.dpl != c.dynamic(pn) {
+		return -1, false
+	}
+	pw := int(c.reg[regRxPwP0+pn])
+	if !pkt.dpl && (pw == 0 || pw != len(pkt.data)) {
+		return -1, false
+	}
+	if int(pkt.pid) == c.lastPID[pn] && bytes.Equal(pkt.data, c.lastData[pn]) {
+		return pn, true
+	}
+	if len(c.rx) == fifoLen {
+		return -1, false
+	}
+	c.rx = append(c.rx, payload{data: pkt.data, pipe: pn})
+	c.reg[regStatus] |= rxDR
+	c.lastPID[pn] = int(pkt.pid)
+	c.lastData[pn] = pkt.data
+	return pn, true
+}
+
+// ack receives ACK sent by prx from its pipe pn and reports whether it was
+// successfully received.
+func (c *Chip) ack(prx *Chip, pn int) bool {
+	if !bytes.Equal(c.rxAddr[0][:c.aw()], c.txAddr[:c.aw()]) {
+		return false
+	}
+	i := -1
+	if prx.reg[regFeature]&(ackPay|dpl) == ackPay|dpl {
+		for k, p := range prx.tx {
+			if p.ack && p.pipe == pn {
+				i = k
+				break
+			}
+		}
+	}
+	if i < 0 {
+		return nrf.MinARD(c.rate(), c.aw(), c.crcLen(), 0) <= c.ard()
+	}
+	data := prx.tx[i].data
+	if c.reg[regFeature]&(ackPay|dpl) != ackPay|dpl || !c.dynamic(0) ||
+		nrf.MinARD(c.rate(), c.aw(), c.crcLen(), len(data)) > c.ard() ||
+		len(c.rx) == fifoLen {
+		return false
+	}
+	prx.tx = append(prx.tx[:i], prx.tx[i+1:]...)
+	prx.reg[regStatus] |= txDS
+	c.rx = append(c.rx, payload{data: data})
+	c.reg[regStatus] |= rxDR
+	return true
+}
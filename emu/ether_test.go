@@ -0,0 +1,168 @@
+package emu_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+// pair returns PTX (a) and PRX (b) chips linked by e and configured for
+// Enhanced ShockBurst with dynamic payload length.
+func pair(t *testing.T, e *emu.Ether, retr int) (a, b *nrf.Device) {
+	a = &nrf.Device{Driver: e.NewChip()}
+	b = &nrf.Device{Driver: e.NewChip()}
+	addr := []byte{1, 2, 3, 4, 5}
+	for _, d := range []*nrf.Device{a, b} {
+		d.SetFeature(nrf.DPL | nrf.AckPay)
+		d.SetRF(nrf.Pwr(0))
+		d.SetCh(76)
+		d.SetALen(5)
+		d.SetRetr(retr, 500)
+		d.SetTxAddr(addr...)
+		d.SetRxAddr(0, addr...)
+		d.SetDynPD(nrf.P0)
+		d.SetAA(nrf.P0)
+		d.SetRxAE(nrf.P0)
+	}
+	a.SetCfg(nrf.EnCRC | nrf.CRCO | nrf.PwrUp)
+	b.SetCfg(nrf.EnCRC | nrf.CRCO | nrf.PwrUp | nrf.PrimRx)
+	if a.Err != nil || b.Err != nil {
+		t.Fatal(a.Err, b.Err)
+	}
+	if err := b.SetCE(1); err != nil {
+		t.Fatal(err)
+	}
+	return a, b
+}
+
+// send transmits pay from a and returns final STATUS.
+func send(t *testing.T, a *nrf.Device, pay []byte) nrf.Status {
+	t.Helper()
+	a.Clear(nrf.TxDS | nrf.MaxRT)
+	a.WriteTxP(pay)
+	if a.Err != nil {
+		t.Fatal(a.Err)
+	}
+	if err := a.SetCE(2); err != nil {
+		t.Fatal(err)
+	}
+	a.NOP()
+	if a.Err != nil {
+		t.Fatal(a.Err)
+	}
+	return a.Status
+}
+
+// recv reads one payload from b or returns nil if Rx FIFO is empty.
+func recv(t *testing.T, b *nrf.Device) []byte {
+	t.Helper()
+	b.NOP()
+	if b.Status.RxPipe() < 0 {
+		return nil
+	}
+	pay := make([]byte, b.RxPLen())
+	b.ReadRxP(pay)
+	b.Clear(nrf.RxDR)
+	if b.Err != nil {
+		t.Fatal(b.Err)
+	}
+	return pay
+}
+
+func TestExchange(t *testing.T) {
+	a, b := pair(t, emu.NewEther(), 3)
+	s := send(t, a, []byte("hello"))
+	if s&nrf.TxDS == 0 || s&nrf.MaxRT != 0 {
+		t.Fatalf("PTX STATUS: %v", s)
+	}
+	if _, arc := a.TxCnt(); arc != 0 {
+		t.Errorf("ARC = %d without loss", arc)
+	}
+	if pay := recv(t, b); string(pay) != "hello" {
+		t.Errorf("PRX received %q", pay)
+	}
+	if pay := recv(t, b); pay != nil {
+		t.Errorf("PRX received second payload %q", pay)
+	}
+}
+
+func TestAckPayload(t *testing.T) {
+	a, b := pair(t, emu.NewEther(), 3)
+	b.WriteAckP(0, []byte("ack"))
+	if b.Err != nil {
+		t.Fatal(b.Err)
+	}
+	s := send(t, a, []byte("ping"))
+	if s&nrf.TxDS == 0 || s&nrf.RxDR == 0 {
+		t.Fatalf("PTX STATUS: %v", s)
+	}
+	if pay := recv(t, a); string(pay) != "ack" {
+		t.Errorf("PTX received ACK payload %q", pay)
+	}
+	if pay := recv(t, b); string(pay) != "ping" {
+		t.Errorf("PRX received %q", pay)
+	}
+}
+
+func TestRetransmit(t *testing.T) {
+	a, b := pair(t, emu.NewEther(), 5)
+	// PRX doesn't listen: PTX should give up after 5 retransmits.
+	b.SetCE(0)
+	s := send(t, a, []byte{1})
+	if s&nrf.MaxRT == 0 || s&nrf.TxDS != 0 {
+		t.Fatalf("PTX STATUS: %v", s)
+	}
+	if plos, arc := a.TxCnt(); plos != 1 || arc != 5 {
+		t.Errorf("PLOS = %d, ARC = %d, want 1, 5", plos, arc)
+	}
+	if pay := recv(t, b); pay != nil {
+		t.Errorf("PRX in standby received %x", pay)
+	}
+	a.FlushTx()
+	a.Clear(nrf.MaxRT)
+	a.SetCh(76) // Reset PLOS.
+	b.SetCE(1)
+	if plos, _ := a.TxCnt(); plos != 0 {
+		t.Errorf("PLOS = %d after write of RF_CH", plos)
+	}
+}
+
+func TestLossyLink(t *testing.T) {
+	e := emu.NewEther()
+	e.Seed(1)
+	a, b := pair(t, e, 15)
+	e.SetLoss(0.3)
+	var retr, delivered int
+	var got [][]byte
+	for n := 0; n < 50; n++ {
+		s := send(t, a, []byte{byte(n)})
+		_, arc := a.TxCnt()
+		retr += arc
+		if s&nrf.MaxRT != 0 {
+			a.FlushTx()
+		} else {
+			delivered++
+		}
+		for pay := recv(t, b); pay != nil; pay = recv(t, b) {
+			got = append(got, pay)
+		}
+	}
+	if retr == 0 {
+		t.Error("no retransmits with 30% loss")
+	}
+	if delivered == 0 {
+		t.Fatal("nothing delivered")
+	}
+	// Lost ACKs cause retransmits of received payloads that PRX must
+	// discard using PID.
+	for i := 1; i < len(got); i++ {
+		if bytes.Equal(got[i], got[i-1]) {
+			t.Errorf("duplicate payload %x", got[i])
+		}
+	}
+	if len(got) < delivered {
+		t.Errorf("PRX received %d payloads, PTX reports %d delivered", len(got), delivered)
+	}
+}
@@ -0,0 +1,59 @@
+package nrf
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Errors caused by invalid arguments. They are always wrapped in ArgError.
+var (
+	ErrChannelRange   = errors.New("nrf: channel not in 0..127")
+	ErrPipeRange      = errors.New("nrf: pipe number not in 0..5")
+	ErrAddrLen        = errors.New("nrf: bad address length")
+	ErrRetrCount      = errors.New("nrf: retransmit count not in 0..15")
+	ErrRetrDelay      = errors.New("nrf: retransmit delay not in 250..4000 µs")
+	ErrPayloadWidth   = errors.New("nrf: payload width not in 0..32")
+	ErrPayloadTooLong = errors.New("nrf: payload longer than 32 bytes")
+	ErrCRCLen         = errors.New("nrf: CRC length not in 0..2")
+	ErrDataRate       = errors.New("nrf: unknown data rate")
+	ErrRegLen         = errors.New("nrf: negative register length")
+)
+
+// Errors returned by Transmitter.
+var (
+	ErrMaxRetransmits = errors.New("nrf: maximum number of retransmits reached")
+	ErrTxFull         = errors.New("nrf: Tx FIFO full")
+)
+
+// Errors returned by Device.Probe and Variant.Check.
+var (
+	ErrNoChip       = errors.New("nrf: chip not detected")
+	ErrNotSupported = errors.New("nrf: feature not supported by chip")
+)
+
+// ArgError is returned (or assigned to Device.Err) if method is called with
+// invalid argument. Use errors.Is to check which argument was invalid.
+type ArgError struct {
+	Err   error // One of Err* variables.
+	Value int   // Invalid value.
+}
+
+func (e *ArgError) Error() string {
+	return e.Err.Error() + ": " + strconv.Itoa(e.Value)
+}
+
+func (e *ArgError) Unwrap() error {
+	return e.Err
+}
+
+func argErr(err error, v int) error {
+	return &ArgError{Err: err, Value: v}
+}
+
+// check sets d.Err to err if d.Err == nil. It reports whether d.Err == nil.
+func (d *Device) check(err error) bool {
+	if d.Err == nil {
+		d.Err = err
+	}
+	return d.Err == nil
+}
@@ -4,6 +4,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/ziutek/bitbang/spi"
 	"github.com/ziutek/ftdi"
 	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/record"
 )
 
 // Connections (FT232RL -- nRF24L01+):
@@ -130,6 +132,15 @@ func newNrfDrv(ma *spi.Master, ce, csn byte) (*nrfDrv, error) {
 	return d, d.SetCE(0)
 }
 
+// Set trace to &nrf.Tracer{W: os.Stdout, Time: true} to print decoded SPI
+// transactions (more readable than spiDrv.debug output).
+var trace *nrf.Tracer
+
+// Set recording to file name prefix (eg. "session") to record SPI transactions
+// of every radio to file (eg. session-A12345.nrfrec) that can be replayed
+// by record.Player in tests.
+var recording string
+
 func setup(udev *ftdi.USBDev) (nrf.Device, *spiDrv) {
 	ft, err := ftdi.OpenUSBDev(udev, ftdi.ChannelAny)
 	checkErr(err)
@@ -157,78 +168,23 @@ func setup(udev *ftdi.USBDev) (nrf.Device, *spiDrv) {
 	nrfd, err := newNrfDrv(ma, CE, CSN)
 	checkErr(err)
 
-	return nrf.Device{Driver: nrfd}, spid
+	var drv nrf.Driver = nrfd
+	if recording != "" {
+		f, err := os.Create(recording + "-" + udev.Serial + ".nrfrec")
+		checkErr(err)
+		drv = (&record.Recorder{W: f}).Wrap(drv)
+	}
+	if trace != nil {
+		drv = trace.Wrap(drv)
+	}
+	return nrf.Device{Driver: drv}, spid
 }
 
 func info(devs []nrf.Device) {
 	for i, dev := range devs {
-		cfg := dev.Config()
-		aa := dev.AA()
-		rxae := dev.RxAE()
-		aw := dev.AW()
-		cnt, dlyus := dev.Retr()
-		ch := dev.Ch()
-		rf := dev.RF()
-		plos, arc := dev.TxCnt()
-		rpd := dev.RPD()
-		rpds := "< -64dBm"
-		if rpd {
-			rpds = "> -64dBm"
-		}
-		var a0, a1, txa [5]byte
-		dev.RxAddr(0, a0[:])
-		dev.RxAddr(1, a1[:])
-		a2 := dev.RxAddr0(2)
-		a3 := dev.RxAddr0(3)
-		a4 := dev.RxAddr0(4)
-		a5 := dev.RxAddr0(5)
-		dev.TxAddr(txa[:])
-		var pw [6]int
-		for i := range pw {
-			pw[i] = dev.RxPW(i)
-		}
-		fifo := dev.FIFO()
-		dynpd := dev.DynPD()
-		feature := dev.Feature()
-
+		s := dev.Snapshot()
 		checkErr(dev.Err)
-
-		fmt.Printf(
-			"Radio %c registers:\n"+
-				" Cfg:   %s\n"+
-				" AA:    %s\n"+
-				" RxAE:  %s\n"+
-				" AW:    %d\n"+
-				" Retr:  %d times, %d us\n"+
-				" Ch:    %d\n"+
-				" RF:    %s\n"+
-				" Stat:  %s\n"+
-				" TxCnt: %d pkt lost, %d retr\n"+
-				" RPD:   %t (%s)\n"+
-				" Addr0: %x\n"+
-				" Addr1: %x\n"+
-				" Addr2: %x\n"+
-				" Addr3: %x\n"+
-				" Addr4: %x\n"+
-				" Addr5: %x\n"+
-				" TxAddr:%x\n",
-			'A'+i,
-			cfg, aa, rxae, aw,
-			cnt, dlyus,
-			ch, rf, dev.Status,
-			plos, arc,
-			rpd, rpds,
-			a0, a1, a2, a3, a4, a5, txa,
-		)
-		for i, pw := range pw {
-			fmt.Printf(" PW%d:   %d\n", i, pw)
-		}
-		fmt.Printf(
-			" FIFO:  %s\n"+
-				" DynPD: %s\n"+
-				" Fature:%s\n",
-			fifo, dynpd, feature,
-		)
+		fmt.Printf("Radio %c registers:\n%s", 'A'+i, s)
 	}
 }
 
@@ -248,27 +204,24 @@ func main() {
 	fmt.Println("\nBefore configuration\n")
 	info(radios)
 
+	fmt.Println("\nSpectrum\n")
+	sc := nrf.Scanner{Dev: &B, Samples: 4}
+	sp, err := sc.Scan(context.Background(), 4, nil)
+	checkErr(err)
+	ch := sp.Best()
+	fmt.Printf("%s\n%s\nBest channel: %d\n", nrf.WaterfallHeader(), &sp, ch)
+
 	cfg := nrf.EnCRC | nrf.CRCO | nrf.PwrUp
 	future := nrf.DPL
-	ch := 125 // max. 125
 	rf := nrf.LNAHC | nrf.DRLow | nrf.Pwr(-18)
 	//rf := nrf.LNAHC | nrf.Pwr(-12)
 	//rf := nrf.LNAHC | nrf.DRHigh | nrf.Pwr(-6)
 	retr := 15
-	var dlyus int
+	ackplen := 0
 	if future&nrf.AckPay != 0 {
-		if rf&nrf.DRLow != 0 {
-			dlyus = 1500
-		} else {
-			dlyus = 500
-		}
-	} else {
-		if rf&nrf.DRLow != 0 {
-			dlyus = 500
-		} else {
-			dlyus = 250
-		}
+		ackplen = 32
 	}
+	dlyus := nrf.MinARD(rf.DataRate(), 5, 2, ackplen)
 	for _, radio := range radios {
 		radio.SetFeature(future)
 		radio.SetRF(rf)
@@ -0,0 +1,92 @@
+// nrfemu runs the nrfbitbang transmission loop using two emulated nRF24L01+
+// transceivers linked by virtual RF medium with packet loss.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+func die(a ...interface{}) {
+	fmt.Fprintln(os.Stderr, a...)
+	os.Exit(1)
+}
+
+func checkErr(err error) {
+	if err == nil {
+		return
+	}
+	die(err)
+}
+
+func main() {
+	ether := emu.NewEther()
+	ether.SetLoss(0.3)
+	ether.SetLatency(100 * time.Microsecond)
+	A := nrf.Device{Driver: ether.NewChip()}
+	B := nrf.Device{Driver: ether.NewChip()}
+	radios := []nrf.Device{A, B}
+
+	settings := nrf.Settings{
+		DataRate:  nrf.DR250k,
+		Pwr:       -18,
+		LNAHC:     true,
+		CRC:       2,
+		Ch:        125,
+		AW:        5,
+		Retr:      15,
+		RetrDelay: 500,
+		TxAddr:    [5]byte{0xe7, 0xe7, 0xe7, 0xe7, 0xe7},
+		Feature:   nrf.DPL,
+	}
+	settings.Pipes[0] = nrf.PipeSettings{
+		Enabled: true,
+		AA:      true,
+		DPL:     true,
+		Addr:    settings.TxAddr,
+	}
+	for _, radio := range radios {
+		checkErr(settings.Apply(&radio))
+	}
+	cfg := nrf.EnCRC | nrf.CRCO | nrf.PwrUp
+	A.SetCfg(cfg)
+	checkErr(A.Err)
+	B.SetCfg(cfg | nrf.PrimRx)
+	checkErr(B.Err)
+
+	const N = 20
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		var (
+			buf  [32]byte
+			lost int
+		)
+		tx := &nrf.Transmitter{Dev: &A}
+		for n := 0; n < N; n++ {
+			buf[31] = byte(n)
+			res, err := tx.Send(ctx, buf[:])
+			if err == nrf.ErrMaxRetransmits {
+				lost++
+				fmt.Printf("A: MaxRT n=%d\n", n)
+				continue
+			}
+			checkErr(err)
+			fmt.Printf("A: TxDS n=%d arc=%d lost=%d\n", n, res.ARC, lost)
+		}
+		cancel()
+	}()
+
+	rx := &nrf.Receiver{Dev: &B}
+	rx.Handler[0] = func(p nrf.Packet) {
+		fmt.Printf("B: pipe=%d %v\n", p.Pipe, p.Data)
+	}
+	if err := rx.Run(ctx); err != context.Canceled {
+		checkErr(err)
+	}
+	fmt.Printf("B: %+v\n", rx.Stats())
+}
@@ -0,0 +1,173 @@
+package fault
+
+import (
+	"context"
+
+	"github.com/ziutek/nrf"
+)
+
+type faultDriver struct {
+	nrf.Driver
+	in *Injector
+}
+
+// pairLen returns number of bytes transfered by out/in pair of oi at i.
+func pairLen(oi [][]byte, i int) int {
+	n := len(oi[i])
+	if i+1 < len(oi) && len(oi[i+1]) > n {
+		n = len(oi[i+1])
+	}
+	return n
+}
+
+// truncate returns first n bytes of transaction oi.
+func truncate(oi [][]byte, n int) [][]byte {
+	var t [][]byte
+	for i := 0; i < len(oi) && n > 0; i += 2 {
+		m := pairLen(oi, i)
+		if m > n {
+			m = n
+		}
+		for k := i; k < i+2 && k < len(oi); k++ {
+			b := oi[k]
+			if len(b) > m {
+				b = b[:m]
+			}
+			t = append(t, b)
+		}
+		n -= m
+	}
+	return t
+}
+
+// fill sets MISO bytes after first n bytes of transaction oi to 0xff
+// (floating MISO line).
+func fill(oi [][]byte, n int) {
+	for i := 0; i < len(oi); i += 2 {
+		m := pairLen(oi, i)
+		if i+1 < len(oi) {
+			in := oi[i+1]
+			k := n
+			if k < 0 {
+				k = 0
+			}
+			for ; k < len(in); k++ {
+				in[k] = 0xff
+			}
+		}
+		n -= m
+	}
+}
+
+// flip flips bit of MISO byte of transaction oi at position pos.
+func flip(oi [][]byte, p *plan) {
+	total := 0
+	for i := 1; i < len(oi); i += 2 {
+		total += len(oi[i])
+	}
+	if total == 0 {
+		return
+	}
+	k := int(p.pos * float64(total))
+	for i := 1; i < len(oi); i += 2 {
+		if k < len(oi[i]) {
+			oi[i][k] ^= 1 << p.bit
+			return
+		}
+		k -= len(oi[i])
+	}
+}
+
+func (d *faultDriver) writeRead(p *plan, oi [][]byte) (n int, err error) {
+	if p.fault[Error] {
+		return 0, ErrInjected
+	}
+	if p.fault[Truncate] {
+		total := 0
+		for i := 0; i < len(oi); i += 2 {
+			total += pairLen(oi, i)
+		}
+		cut := int(p.pos * float64(total))
+		if cut > 0 {
+			n, err = d.Driver.WriteRead(truncate(oi, cut)...)
+		}
+		fill(oi, cut)
+		if err == nil {
+			err = ErrTruncated
+		}
+	} else {
+		n, err = d.Driver.WriteRead(oi...)
+	}
+	if p.fault[Flip] {
+		flip(oi, p)
+	}
+	return n, err
+}
+
+func (d *faultDriver) WriteRead(oi ...[]byte) (n int, err error) {
+	p := d.in.next(false)
+	d.in.delay(p)
+	return d.writeRead(p, oi)
+}
+
+// WriteReadBatch performs transactions that precede first Error or Truncate
+// fault using one WriteReadBatch call of wrapped driver.
+func (d *faultDriver) WriteReadBatch(txs ...[][]byte) error {
+	b, ok := d.Driver.(nrf.Batcher)
+	if !ok {
+		for _, oi := range txs {
+			if _, err := d.WriteRead(oi...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	plans := make([]*plan, 0, len(txs))
+	cut := len(txs)
+	for i := range txs {
+		p := d.in.next(false)
+		d.in.delay(p)
+		plans = append(plans, p)
+		if p.fault[Error] || p.fault[Truncate] {
+			cut = i
+			break
+		}
+	}
+	if cut > 0 {
+		if err := b.WriteReadBatch(txs[:cut]...); err != nil {
+			return err
+		}
+	}
+	for i, p := range plans[:cut] {
+		if p.fault[Flip] {
+			flip(txs[i], p)
+		}
+	}
+	if cut == len(txs) {
+		return nil
+	}
+	_, err := d.writeRead(plans[cut], txs[cut])
+	return err
+}
+
+func (d *faultDriver) SetCE(v int) error {
+	p := d.in.next(true)
+	d.in.delay(p)
+	if p.fault[CEError] {
+		return ErrInjected
+	}
+	return d.Driver.SetCE(v)
+}
+
+type faultIRQDriver struct {
+	*faultDriver
+	w nrf.IRQWaiter
+}
+
+func (d *faultIRQDriver) WaitIRQ(ctx context.Context) error {
+	return d.w.WaitIRQ(ctx)
+}
+
+func (d *faultIRQDriver) IRQ() (bool, error) {
+	return d.w.IRQ()
+}
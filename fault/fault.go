@@ -0,0 +1,177 @@
+// Package fault provides nrf.Driver decorator that injects faults (errors,
+// corrupted and truncated transfers, latency) to exercise error paths of code
+// that uses nrf.Device, eg. to test how it copes with flaky USB-serial link.
+package fault
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ziutek/nrf"
+)
+
+// Errors returned by Driver returned by Injector.Wrap.
+var (
+	ErrInjected  = errors.New("fault: injected error")
+	ErrTruncated = errors.New("fault: transfer truncated")
+)
+
+// Kind is kind of injected fault.
+type Kind int
+
+const (
+	// Error: WriteRead returns ErrInjected without performing transaction.
+	Error Kind = iota
+
+	// Flip: one random bit of MISO data (including STATUS) is flipped.
+	Flip
+
+	// Truncate: only random number of first bytes of transaction is
+	// transfered. Remaining MISO bytes are set to 0xff and WriteRead returns
+	// ErrTruncated.
+	Truncate
+
+	// Delay: WriteRead or SetCE call is delayed by Injector.Latency.
+	Delay
+
+	// CEError: SetCE returns ErrInjected without changing CE line.
+	CEError
+
+	NumKinds
+)
+
+var kindNames = [NumKinds]string{"Error", "Flip", "Truncate", "Delay", "CEError"}
+
+func (k Kind) String() string {
+	if uint(k) < uint(NumKinds) {
+		return kindNames[k]
+	}
+	return "Kind(?)"
+}
+
+// Rule describes when fault is injected. Calls are numbered from 1. SetCE
+// calls are numbered separately from WriteRead calls (every transaction of
+// WriteReadBatch is counted as one WriteRead call).
+type Rule struct {
+	Prob  float64 // Probability of fault for every call.
+	Every int     // If > 0, fault in every Every-th call.
+	At    []int   // Numbers of calls with fault.
+}
+
+func (r *Rule) hit(n int, rnd *rand.Rand) bool {
+	if r.Every > 0 && n%r.Every == 0 {
+		return true
+	}
+	for _, a := range r.At {
+		if a == n {
+			return true
+		}
+	}
+	return r.Prob > 0 && rnd.Float64() < r.Prob
+}
+
+// Injector describes faults injected by Driver returned by Wrap.
+type Injector struct {
+	Rules   [NumKinds]Rule
+	Latency time.Duration // Delay of call for Delay fault.
+	Seed    int64         // Seed of pseudo-random generator.
+
+	// If not nil OnFault is called for every injected fault with number of
+	// call.
+	OnFault func(k Kind, call int)
+
+	mu    sync.Mutex
+	rnd   *rand.Rand
+	calls int
+	ces   int
+	count [NumKinds]int
+}
+
+// Wrap returns Driver that calls drv and injects faults described by in.
+// Returned Driver implements IRQWaiter if drv implements it. It always
+// implements Batcher.
+func (in *Injector) Wrap(drv nrf.Driver) nrf.Driver {
+	fd := &faultDriver{Driver: drv, in: in}
+	if w, ok := drv.(nrf.IRQWaiter); ok {
+		return &faultIRQDriver{fd, w}
+	}
+	return fd
+}
+
+// Count returns number of injected faults of kind k.
+func (in *Injector) Count(k Kind) int {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.count[k]
+}
+
+// Reset resets call numbers and fault counters. Pseudo-random generator is
+// reinitialized using in.Seed.
+func (in *Injector) Reset() {
+	in.mu.Lock()
+	in.rnd = nil
+	in.calls = 0
+	in.ces = 0
+	in.count = [NumKinds]int{}
+	in.mu.Unlock()
+}
+
+// plan contains faults chosen for one call.
+type plan struct {
+	call  int
+	fault [NumKinds]bool
+	pos   float64 // Random position for Flip and Truncate, 0 <= pos < 1.
+	bit   uint
+}
+
+// next chooses faults for next WriteRead (ce == false) or SetCE call.
+func (in *Injector) next(ce bool) *plan {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.rnd == nil {
+		in.rnd = rand.New(rand.NewSource(in.Seed))
+	}
+	p := new(plan)
+	kinds := []Kind{Error, Flip, Truncate, Delay}
+	if ce {
+		in.ces++
+		p.call = in.ces
+		kinds = []Kind{CEError, Delay}
+	} else {
+		in.calls++
+		p.call = in.calls
+	}
+	for _, k := range kinds {
+		p.fault[k] = in.Rules[k].hit(p.call, in.rnd)
+	}
+	if p.fault[Error] {
+		// Transaction isn't performed at all.
+		p.fault[Flip] = false
+		p.fault[Truncate] = false
+	}
+	for k, f := range p.fault {
+		if f {
+			in.count[k]++
+		}
+	}
+	p.pos = in.rnd.Float64()
+	p.bit = uint(in.rnd.Intn(8))
+	return p
+}
+
+// delay sleeps if p contains Delay fault and calls in.OnFault.
+func (in *Injector) delay(p *plan) {
+	if p.fault[Delay] {
+		time.Sleep(in.Latency)
+	}
+	if in.OnFault == nil {
+		return
+	}
+	for k, f := range p.fault {
+		if f {
+			in.OnFault(Kind(k), p.call)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package fault_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+	"github.com/ziutek/nrf/fault"
+)
+
+// wrap returns device that uses emulated chip c wrapped by in and device that
+// uses c directly.
+func wrap(in *fault.Injector) (d, chip *nrf.Device) {
+	c := emu.NewChip()
+	return &nrf.Device{Driver: in.Wrap(c)}, &nrf.Device{Driver: c}
+}
+
+func TestError(t *testing.T) {
+	in := &fault.Injector{}
+	in.Rules[fault.Error] = fault.Rule{Every: 3, At: []int{2}}
+	var calls []int
+	in.OnFault = func(k fault.Kind, call int) {
+		if k != fault.Error {
+			t.Errorf("%v fault injected", k)
+		}
+		calls = append(calls, call)
+	}
+	d, chip := wrap(in)
+	for ch := 1; ch <= 6; ch++ {
+		d.SetCh(ch)
+		want := []error{nil, fault.ErrInjected, fault.ErrInjected, nil, nil, fault.ErrInjected}[ch-1]
+		if d.Err != want {
+			t.Errorf("call %d: error %v, want %v", ch, d.Err, want)
+		}
+		if want == nil && chip.Ch() != ch || want != nil && chip.Ch() == ch {
+			t.Errorf("call %d: RF_CH = %d", ch, chip.Ch())
+		}
+		d.Err = nil
+	}
+	if len(calls) != 3 || calls[0] != 2 || calls[2] != 6 || in.Count(fault.Error) != 3 {
+		t.Errorf("faults in calls %v, count %d", calls, in.Count(fault.Error))
+	}
+	in.Reset()
+	if d.SetCh(9); d.Err != nil || in.Count(fault.Error) != 0 {
+		t.Errorf("after Reset: %v, count %d", d.Err, in.Count(fault.Error))
+	}
+}
+
+func TestCEError(t *testing.T) {
+	in := &fault.Injector{}
+	in.Rules[fault.CEError].At = []int{1}
+	in.Rules[fault.Error].At = []int{1} // WriteRead calls are counted separately.
+	c := emu.NewChip()
+	drv := in.Wrap(c)
+	if _, ok := drv.(nrf.IRQWaiter); !ok {
+		t.Error("IRQWaiter not implemented")
+	}
+	if err := drv.SetCE(1); err != fault.ErrInjected || c.CE() {
+		t.Errorf("first SetCE(1): %v, CE %t", err, c.CE())
+	}
+	if err := drv.SetCE(1); err != nil || !c.CE() {
+		t.Errorf("second SetCE(1): %v, CE %t", err, c.CE())
+	}
+}
+
+func TestFlip(t *testing.T) {
+	in := &fault.Injector{Seed: 1}
+	in.Rules[fault.Flip].Every = 1
+	d, chip := wrap(in)
+	chip.SetTxAddr(1, 2, 3, 4, 5)
+	r := nrf.Radio{Driver: d.Driver}
+	for i := 0; i < 20; i++ {
+		var addr [5]byte
+		stat, err := r.TxAddr(addr[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		chip.NOP()
+		bits := 0
+		for k, b := range append([]byte{byte(stat)}, addr[:]...) {
+			want := append([]byte{byte(chip.Status)}, 1, 2, 3, 4, 5)[k]
+			for x := b ^ want; x != 0; x &= x - 1 {
+				bits++
+			}
+		}
+		if bits != 1 {
+			t.Fatalf("%d bits flipped: %v %x", bits, stat, addr)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	in := &fault.Injector{Seed: 3}
+	in.Rules[fault.Truncate].Every = 1
+	d, chip := wrap(in)
+	chip.SetTxAddr(1, 2, 3, 4, 5)
+	r := nrf.Radio{Driver: d.Driver}
+	want := []byte{1, 2, 3, 4, 5}
+	for i := 0; i < 20; i++ {
+		var addr [5]byte
+		if _, err := r.TxAddr(addr[:]); err != fault.ErrTruncated {
+			t.Fatalf("error %v, want ErrTruncated", err)
+		}
+		// Bytes read before cut are valid, others are 0xff.
+		k := 0
+		for k < len(addr) && addr[k] == want[k] {
+			k++
+		}
+		if !bytes.Equal(addr[k:], bytes.Repeat([]byte{0xff}, 5-k)) {
+			t.Errorf("truncated read: %x", addr)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	in := &fault.Injector{}
+	in.Rules[fault.Error].At = []int{2}
+	d, chip := wrap(in)
+	var b nrf.Batch
+	b.SetReg(5, 1)
+	b.SetReg(6, 0x26)
+	b.SetReg(5, 3)
+	d.Exec(&b)
+	if d.Err != fault.ErrInjected {
+		t.Errorf("error %v, want ErrInjected", d.Err)
+	}
+	if ch, rf := chip.Ch(), chip.RF(); ch != 1 || rf == 0x26 {
+		t.Errorf("RF_CH %d, RF_SETUP %v: batch not stopped at fault", ch, rf)
+	}
+}
+
+func TestDelaySeed(t *testing.T) {
+	in := &fault.Injector{Latency: 2 * time.Millisecond, Seed: 7}
+	in.Rules[fault.Delay].At = []int{1}
+	in.Rules[fault.Error].Prob = 0.5
+	d, _ := wrap(in)
+	var run [2][]int
+	for i := range run {
+		in.Reset()
+		in.OnFault = func(k fault.Kind, call int) {
+			if k == fault.Error {
+				run[i] = append(run[i], call)
+			}
+		}
+		start := time.Now()
+		for k := 0; k < 20; k++ {
+			d.NOP()
+			d.Err = nil
+		}
+		if time.Since(start) < in.Latency {
+			t.Error("call not delayed")
+		}
+	}
+	if len(run[0]) == 0 || len(run[0]) == 20 || len(run[0]) != len(run[1]) {
+		t.Fatalf("faults in calls %v and %v", run[0], run[1])
+	}
+	for i, c := range run[0] {
+		if run[1][i] != c {
+			t.Errorf("faults after Reset differ: %v, %v", run[0], run[1])
+			break
+		}
+	}
+	if s := fault.CEError.String(); s != "CEError" {
+		t.Errorf("String: %s", s)
+	}
+}
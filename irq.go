@@ -0,0 +1,54 @@
+package nrf
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultPoll is STATUS polling interval used by WaitIRQ if poll <= 0.
+const DefaultPoll = 100 * time.Microsecond
+
+// WaitIRQ waits for interrupt. If d.Driver implements IRQWaiter its WaitIRQ
+// method is used. Otherwise CONFIG register is read once and STATUS register
+// is polled (using NOP command) every poll until one of RxDR, TxDS, MaxRT
+// flags that isn't masked in CONFIG is set, like IRQ line would be activated.
+// After return d.Status contains current value of STATUS register. WaitIRQ returns ctx.Err() if ctx
+// is done before interrupt occurs or d.Err if it isn't nil.
+func (d *Device) WaitIRQ(ctx context.Context, poll time.Duration) error {
+	if d.Err != nil {
+		return d.Err
+	}
+	if w, ok := d.Driver.(IRQWaiter); ok {
+		if err := w.WaitIRQ(ctx); err != nil {
+			return err
+		}
+		d.NOP()
+		return d.Err
+	}
+	if poll <= 0 {
+		poll = DefaultPoll
+	}
+	// MASK_* bits in CONFIG have the same positions as flags in STATUS.
+	irqs := (RxDR | TxDS | MaxRT) &^ Status(d.Config())
+	var t *time.Timer
+	for {
+		d.NOP()
+		if d.Err != nil {
+			return d.Err
+		}
+		if d.Status&irqs != 0 {
+			return nil
+		}
+		if t == nil {
+			t = time.NewTimer(poll)
+			defer t.Stop()
+		} else {
+			t.Reset(poll)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
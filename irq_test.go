@@ -0,0 +1,69 @@
+package nrf_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+// noIRQ hides IRQWaiter implemented by emulated chip, so Device.WaitIRQ polls
+// STATUS.
+type noIRQ struct {
+	c *emu.Chip
+}
+
+func (d noIRQ) WriteRead(oi ...[]byte) (int, error) { return d.c.WriteRead(oi...) }
+func (d noIRQ) SetCE(v int) error                   { return d.c.SetCE(v) }
+
+// maxRT returns PTX devices, one with IRQWaiter and one without it, that have
+// MaxRT flag set and mask in CONFIG.
+func maxRT(t *testing.T, mask nrf.Config) []*nrf.Device {
+	var ds []*nrf.Device
+	for _, poll := range []bool{false, true} {
+		a, _ := link(t, 0) // PRX with CE low doesn't receive.
+		if poll {
+			a.Driver = noIRQ{a.Driver.(*emu.Chip)}
+		}
+		a.SetCfg(a.Config() | mask)
+		a.WriteTxP([]byte{1})
+		if a.Err != nil {
+			t.Fatal(a.Err)
+		}
+		if err := a.SetCE(2); err != nil {
+			t.Fatal(err)
+		}
+		ds = append(ds, a)
+	}
+	return ds
+}
+
+func TestWaitIRQ(t *testing.T) {
+	for _, d := range maxRT(t, 0) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := d.WaitIRQ(ctx, 50*time.Microsecond)
+		cancel()
+		if err != nil {
+			t.Fatalf("%T: %v", d.Driver, err)
+		}
+		if d.Status&nrf.MaxRT == 0 {
+			t.Errorf("%T: STATUS %v", d.Driver, d.Status)
+		}
+	}
+}
+
+func TestWaitIRQMasked(t *testing.T) {
+	for _, d := range maxRT(t, nrf.MaskMaxRT) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+		err := d.WaitIRQ(ctx, 50*time.Microsecond)
+		cancel()
+		if err != context.DeadlineExceeded {
+			t.Errorf("%T: error %v, want DeadlineExceeded (MaxRT masked)", d.Driver, err)
+		}
+		if d.Err != nil {
+			t.Errorf("%T: ctx error is sticky: %v", d.Driver, d.Err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package nrf
+
+import "sync"
+
+// LinkCounters contains link statistics collected by LinkStats.
+type LinkCounters struct {
+	Sent      int     // Number of transmissions.
+	Delivered int     // Number of acknowledged transmissions.
+	MaxRT     int     // Number of transmissions that reached MaxRT.
+	Retries   int     // Total number of retransmits.
+	RetrHist  [16]int // RetrHist[n]: number of deliveries after n retransmits.
+	Lost      int     // Number of lost packets (sum of PLOS increments).
+	RPDHigh   int     // Number of deliveries with RPD set (ACK RP > -64 dBm).
+}
+
+// DeliveryRatio returns Delivered/Sent.
+func (c *LinkCounters) DeliveryRatio() float64 {
+	if c.Sent == 0 {
+		return 0
+	}
+	return float64(c.Delivered) / float64(c.Sent)
+}
+
+// RPDRatio returns RPDHigh/Delivered.
+func (c *LinkCounters) RPDRatio() float64 {
+	if c.Delivered == 0 {
+		return 0
+	}
+	return float64(c.RPDHigh) / float64(c.Delivered)
+}
+
+// LinkStats collects PTX link statistics. Set Transmitter.Stats to collect
+// them for every Send or call Update after every transmission. Counters can be
+// read from any goroutine.
+type LinkStats struct {
+	mu   sync.Mutex
+	c    LinkCounters
+	plos int
+}
+
+// Update samples OBSERVE_TX and RPD registers of d after transmission that
+// ended with TxDS (delivered == true) or MaxRT. PLOS counter saturates at 15
+// so Update resets it by rewriting RF_CH when it reaches this value. Update
+// should be called with CE low.
+func (ls *LinkStats) Update(d *Device, delivered bool) {
+	plos, arc := d.TxCnt()
+	rpd := delivered && d.RPD()
+	if plos == 15 {
+		d.SetCh(d.Ch())
+	}
+	if d.Err != nil {
+		return
+	}
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	c := &ls.c
+	c.Sent++
+	c.Retries += arc
+	if delivered {
+		c.Delivered++
+		c.RetrHist[arc]++
+		if rpd {
+			c.RPDHigh++
+		}
+	} else {
+		c.MaxRT++
+	}
+	if plos < ls.plos {
+		ls.plos = 0 // PLOS was reset by someone else.
+	}
+	c.Lost += plos - ls.plos
+	ls.plos = plos
+	if plos == 15 {
+		ls.plos = 0
+	}
+}
+
+// Counters returns current values of counters.
+func (ls *LinkStats) Counters() LinkCounters {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	return ls.c
+}
+
+// Reset zeroes counters.
+func (ls *LinkStats) Reset() {
+	ls.mu.Lock()
+	ls.c = LinkCounters{}
+	ls.mu.Unlock()
+}
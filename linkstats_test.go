@@ -0,0 +1,46 @@
+package nrf_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ziutek/nrf"
+)
+
+func TestLinkStats(t *testing.T) {
+	a, b := link(t, 1)
+	ls := new(nrf.LinkStats)
+	tx := &nrf.Transmitter{Dev: a, Stats: ls}
+	// PRX with CE low doesn't receive, so every packet is lost. PLOS saturates
+	// at 15 so LinkStats must reset it to count all of them.
+	for i := 0; i < 20; i++ {
+		if i == 7 {
+			a.SetCh(a.Ch()) // Reset PLOS behind LinkStats back.
+		}
+		if _, err := tx.Send(context.Background(), []byte{byte(i)}); err != nrf.ErrMaxRetransmits {
+			t.Fatalf("%d: error %v, want ErrMaxRetransmits", i, err)
+		}
+	}
+	if err := b.SetCE(1); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := tx.Send(context.Background(), []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := ls.Counters()
+	if c.Sent != 22 || c.Delivered != 2 || c.MaxRT != 20 || c.Lost != 20 {
+		t.Errorf("counters: %+v", c)
+	}
+	if c.Retries != 20 || c.RetrHist[0] != 2 {
+		t.Errorf("retransmits: %d, %v", c.Retries, c.RetrHist)
+	}
+	if r := c.DeliveryRatio(); r != 2.0/22 {
+		t.Errorf("DeliveryRatio() = %g", r)
+	}
+	ls.Reset()
+	if c := ls.Counters(); c != (nrf.LinkCounters{}) {
+		t.Errorf("after Reset: %+v", c)
+	}
+}
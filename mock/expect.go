@@ -0,0 +1,112 @@
+package mock
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ziutek/nrf"
+)
+
+// Expect describes expected driver call and reply to it. Its methods modify
+// and return Expect so they can be chained.
+type Expect struct {
+	ce    bool   // SetCE call.
+	cmd   byte   // SPI command or CE value.
+	data  []byte // Expected data after command byte, nil means any data.
+	reply []byte // MISO bytes: STATUS and data.
+	err   error
+
+	times int // -1 means any number of times.
+	used  int
+	any   bool // Unordered.
+	when  int  // Required CE value, -1 means any.
+}
+
+// Reply sets MISO bytes returned by chip (first byte is STATUS). Bytes that
+// are not specified are returned as zeros.
+func (e *Expect) Reply(miso ...byte) *Expect {
+	e.reply = miso
+	return e
+}
+
+// Status sets value of STATUS register returned by chip.
+func (e *Expect) Status(s nrf.Status) *Expect {
+	if len(e.reply) == 0 {
+		e.reply = []byte{0}
+	}
+	e.reply[0] = byte(s)
+	return e
+}
+
+// Err sets error returned by driver call. MISO bytes are still returned.
+func (e *Expect) Err(err error) *Expect {
+	e.err = err
+	return e
+}
+
+// Times sets number of expected calls (default 1).
+func (e *Expect) Times(n int) *Expect {
+	e.times = n
+	return e
+}
+
+// AnyTimes allows any number of calls (including zero).
+func (e *Expect) AnyTimes() *Expect {
+	e.times = -1
+	return e
+}
+
+// AnyOrder makes e unordered: it can match a call at any time, regardless of
+// ordered expectations declared before and after it.
+func (e *Expect) AnyOrder() *Expect {
+	e.any = true
+	return e
+}
+
+// WhenCE asserts that CE line is low (v==0) or high (v==1) during call.
+func (e *Expect) WhenCE(v int) *Expect {
+	e.when = v
+	return e
+}
+
+func (e *Expect) satisfied() bool {
+	return e.times < 0 || e.used >= e.times
+}
+
+func (e *Expect) exhausted() bool {
+	return e.times >= 0 && e.used >= e.times
+}
+
+func (e *Expect) match(c *call) bool {
+	if e.ce != c.ce {
+		return false
+	}
+	if e.ce {
+		return e.cmd == byte(c.v)
+	}
+	if len(c.mosi) == 0 || c.mosi[0] != e.cmd {
+		return false
+	}
+	return e.data == nil || bytes.Equal(c.mosi[1:], e.data)
+}
+
+func (e *Expect) String() string {
+	var s string
+	if e.ce {
+		s = fmt.Sprintf("SetCE(%d)", e.cmd)
+	} else {
+		s = nrf.Decode(append([]byte{e.cmd}, e.data...), e.reply)
+	}
+	if e.times < 0 {
+		s += fmt.Sprintf(" [any times, called %d]", e.used)
+	} else if e.times != 1 {
+		s += fmt.Sprintf(" [%d times, called %d]", e.times, e.used)
+	}
+	if e.any {
+		s += " [any order]"
+	}
+	if e.err != nil {
+		s += " ! " + e.err.Error()
+	}
+	return s
+}
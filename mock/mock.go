@@ -0,0 +1,288 @@
+// Package mock provides scriptable nrf.Driver for unit tests of code that
+// uses nrf.Device. Test declares expected SPI commands (not raw bytes) and CE
+// changes with replies to them:
+//
+//	m := mock.New(t)
+//	m.ExpectSetReg(0x05, 0x4c)          // W_REGISTER RF_CH, reply STATUS 0x0e
+//	m.ExpectRxPLen(0x20).Status(0x40)   // R_RX_PL_WID, reply 0x40 0x20
+//	m.ExpectNOP().AnyTimes().AnyOrder() // STATUS polling
+//	m.ExpectCE(1)
+//	dev := &nrf.Device{Driver: m}
+//
+// Expectations are ordered by default. Unexpected calls and expectations that
+// weren't met at the end of test are reported using testing.TB.
+package mock
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ziutek/nrf"
+)
+
+// ErrUnexpected is returned by Driver for unexpected call.
+var ErrUnexpected = errors.New("mock: unexpected call")
+
+// Driver is mock nrf.Driver. It also implements nrf.Batcher.
+type Driver struct {
+	// Status is STATUS value returned by expectations created by Expect*
+	// methods (0x0e by default).
+	Status nrf.Status
+
+	tb  testing.TB
+	mu  sync.Mutex
+	exp []*Expect
+	pos int // First ordered expectation that can match.
+	ce  int
+}
+
+// New returns Driver that reports errors to tb. Driver.Finish is called at
+// end of test using tb.Cleanup.
+func New(tb testing.TB) *Driver {
+	m := &Driver{Status: 0x0e, tb: tb}
+	tb.Cleanup(m.Finish)
+	return m
+}
+
+type call struct {
+	ce   bool
+	v    int
+	mosi []byte
+}
+
+func (c *call) String() string {
+	if c.ce {
+		return fmt.Sprintf("SetCE(%d)", c.v)
+	}
+	// Show only data sent by host.
+	s, _, _ := strings.Cut(nrf.Decode(c.mosi, nil), " (STATUS")
+	s, _, _ = strings.Cut(s, " ->")
+	return s
+}
+
+func (m *Driver) add(e *Expect) *Expect {
+	e.times = 1
+	e.when = -1
+	m.mu.Lock()
+	m.exp = append(m.exp, e)
+	m.mu.Unlock()
+	return e
+}
+
+// Expect adds expectation of SPI command cmd followed by data. If data is
+// empty any data matches. Reply contains only STATUS byte.
+func (m *Driver) Expect(cmd byte, data ...byte) *Expect {
+	return m.add(&Expect{cmd: cmd, data: data, reply: []byte{byte(m.Status)}})
+}
+
+// expectRead adds expectation of command that reads val from chip.
+func (m *Driver) expectRead(cmd byte, val []byte) *Expect {
+	reply := append([]byte{byte(m.Status)}, val...)
+	return m.add(&Expect{cmd: cmd, reply: reply})
+}
+
+// ExpectReg adds expectation of R_REGISTER command that returns val.
+func (m *Driver) ExpectReg(addr byte, val ...byte) *Expect {
+	return m.expectRead(addr&0x1f, val)
+}
+
+// ExpectSetReg adds expectation of W_REGISTER command that writes val (any
+// value if val is empty).
+func (m *Driver) ExpectSetReg(addr byte, val ...byte) *Expect {
+	return m.Expect(0x20|addr&0x1f, val...)
+}
+
+// ExpectClear adds expectation of write to STATUS register that clears
+// specified flags.
+func (m *Driver) ExpectClear(flags nrf.Status) *Expect {
+	return m.ExpectSetReg(7, byte(flags))
+}
+
+// ExpectReadRxP adds expectation of R_RX_PAYLOAD command that returns pay.
+func (m *Driver) ExpectReadRxP(pay ...byte) *Expect {
+	return m.expectRead(0x61, pay)
+}
+
+// ExpectRxPLen adds expectation of R_RX_PL_WID command that returns n.
+func (m *Driver) ExpectRxPLen(n byte) *Expect {
+	return m.expectRead(0x60, []byte{n})
+}
+
+// ExpectWriteTxP adds expectation of W_TX_PAYLOAD command that writes pay.
+func (m *Driver) ExpectWriteTxP(pay ...byte) *Expect {
+	return m.Expect(0xa0, pay...)
+}
+
+// ExpectWriteTxPNoAck adds expectation of W_TX_PAYLOAD_NOACK command that
+// writes pay.
+func (m *Driver) ExpectWriteTxPNoAck(pay ...byte) *Expect {
+	return m.Expect(0xb0, pay...)
+}
+
+// ExpectWriteAckP adds expectation of W_ACK_PAYLOAD command that writes pay
+// for pipe pn.
+func (m *Driver) ExpectWriteAckP(pn int, pay ...byte) *Expect {
+	return m.Expect(0xa8|byte(pn&7), pay...)
+}
+
+// ExpectFlushTx adds expectation of FLUSH_TX command.
+func (m *Driver) ExpectFlushTx() *Expect {
+	return m.Expect(0xe1, []byte{}...)
+}
+
+// ExpectFlushRx adds expectation of FLUSH_RX command.
+func (m *Driver) ExpectFlushRx() *Expect {
+	return m.Expect(0xe2, []byte{}...)
+}
+
+// ExpectReuseTxP adds expectation of REUSE_TX_PL command.
+func (m *Driver) ExpectReuseTxP() *Expect {
+	return m.Expect(0xe3, []byte{}...)
+}
+
+// ExpectActivate adds expectation of ACTIVATE command with argument b.
+func (m *Driver) ExpectActivate(b byte) *Expect {
+	return m.Expect(0x50, b)
+}
+
+// ExpectNOP adds expectation of NOP command.
+func (m *Driver) ExpectNOP() *Expect {
+	return m.Expect(0xff, []byte{}...)
+}
+
+// ExpectCE adds expectation of SetCE(v) call.
+func (m *Driver) ExpectCE(v int) *Expect {
+	return m.add(&Expect{ce: true, cmd: byte(v)})
+}
+
+// CE returns current (last set) value of CE line.
+func (m *Driver) CE() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ce
+}
+
+// find returns expectation that matches c.
+func (m *Driver) find(c *call) *Expect {
+	for i := m.pos; i < len(m.exp); i++ {
+		e := m.exp[i]
+		if e.any {
+			continue
+		}
+		if !e.exhausted() && e.match(c) {
+			m.pos = i
+			return e
+		}
+		if !e.satisfied() {
+			break
+		}
+	}
+	for _, e := range m.exp {
+		if e.any && !e.exhausted() && e.match(c) {
+			return e
+		}
+	}
+	return nil
+}
+
+func (m *Driver) pending() string {
+	var s string
+	for _, e := range m.exp {
+		if !e.satisfied() {
+			s += "\n\t" + e.String()
+		}
+	}
+	if s == "" {
+		return "\n\t(none)"
+	}
+	return s
+}
+
+func (m *Driver) do(c *call) (*Expect, error) {
+	e := m.find(c)
+	if e == nil {
+		m.tb.Helper()
+		m.tb.Errorf("mock: unexpected %s\npending expectations:%s", c, m.pending())
+		return nil, ErrUnexpected
+	}
+	e.used++
+	if e.when >= 0 && e.when != m.ce {
+		m.tb.Helper()
+		m.tb.Errorf("mock: %s called with CE=%d, want CE=%d", c, m.ce, e.when)
+	}
+	return e, e.err
+}
+
+// WriteRead returns n equal to number of bytes transferred over SPI: sum of
+// max(len(out), len(in)) of all pairs.
+func (m *Driver) WriteRead(oi ...[]byte) (n int, err error) {
+	c := &call{}
+	c.mosi, _ = nrf.Flatten(oi)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tb.Helper()
+	e, err := m.do(c)
+	if e == nil {
+		return 0, err
+	}
+	// Scatter reply to in slices.
+	miso := e.reply
+	for i := 0; i < len(oi); i += 2 {
+		k := len(oi[i])
+		if i+1 < len(oi) {
+			in := oi[i+1]
+			if len(in) > k {
+				k = len(in)
+			}
+			for j := range in {
+				in[j] = 0
+			}
+			copy(in, miso)
+		}
+		if k > len(miso) {
+			k = len(miso)
+		}
+		miso = miso[k:]
+	}
+	return len(c.mosi), err
+}
+
+func (m *Driver) WriteReadBatch(txs ...[][]byte) error {
+	m.tb.Helper()
+	for _, oi := range txs {
+		if _, err := m.WriteRead(oi...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Driver) SetCE(v int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tb.Helper()
+	e, err := m.do(&call{ce: true, v: v})
+	if e != nil && err == nil {
+		m.ce = v & 1
+		if v == 2 {
+			m.ce = 0
+		}
+	}
+	return err
+}
+
+// Finish reports expectations that weren't met. It is called automatically
+// at end of test.
+func (m *Driver) Finish() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.exp {
+		if !e.satisfied() {
+			m.tb.Helper()
+			m.tb.Errorf("mock: missing call: %s", e)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package mock_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/mock"
+)
+
+// fakeTB collects errors reported by mock.Driver.
+type fakeTB struct {
+	testing.TB
+	errs    []string
+	cleanup []func()
+}
+
+func (tb *fakeTB) Helper()          {}
+func (tb *fakeTB) Cleanup(f func()) { tb.cleanup = append(tb.cleanup, f) }
+
+func (tb *fakeTB) Errorf(format string, args ...interface{}) {
+	tb.errs = append(tb.errs, fmt.Sprintf(format, args...))
+}
+
+func (tb *fakeTB) finish() {
+	for _, f := range tb.cleanup {
+		f()
+	}
+}
+
+func TestDevice(t *testing.T) {
+	m := mock.New(t)
+	m.ExpectNOP().AnyTimes().AnyOrder()
+	m.ExpectSetReg(5, 76)
+	m.ExpectReg(5, 76)
+	m.ExpectCE(1)
+	m.ExpectRxPLen(3).Status(0x42).WhenCE(1)
+	m.ExpectReadRxP(1, 2, 3).Status(0x42)
+	m.ExpectClear(nrf.RxDR).Times(2)
+	d := &nrf.Device{Driver: m}
+	d.NOP()
+	d.SetCh(76)
+	d.NOP()
+	ch := d.Ch()
+	d.SetCE(1)
+	n := d.RxPLen()
+	pn := d.RxPipe()
+	pay := make([]byte, n)
+	d.ReadRxP(pay)
+	d.Clear(nrf.RxDR)
+	d.Clear(nrf.RxDR)
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	if ch != 76 || n != 3 || pn != 1 || string(pay) != "\x01\x02\x03" || m.CE() != 1 {
+		t.Errorf("RF_CH %d, plen %d, pipe %d, payload %x, CE %d", ch, n, pn, pay, m.CE())
+	}
+}
+
+func TestWriteReadN(t *testing.T) {
+	m := mock.New(t)
+	m.ExpectReadRxP(1, 2)
+	m.ExpectSetReg(0x10, 1, 2, 3)
+	var stat [1]byte
+	pay := make([]byte, 2)
+	// R_RX_PAYLOAD: 1 + max(0, 2) bytes.
+	if n, err := m.WriteRead([]byte{0x61}, stat[:], nil, pay); n != 3 || err != nil {
+		t.Errorf("R_RX_PAYLOAD: n=%d, %v", n, err)
+	}
+	// W_REGISTER: max(1, 1) + max(3, 0) bytes.
+	if n, err := m.WriteRead([]byte{0x30}, stat[:], []byte{1, 2, 3}); n != 4 || err != nil {
+		t.Errorf("W_REGISTER: n=%d, %v", n, err)
+	}
+}
+
+func TestErr(t *testing.T) {
+	m := mock.New(t)
+	fail := errors.New("SPI failure")
+	m.ExpectReg(5, 9).Err(fail)
+	d := &nrf.Device{Driver: m}
+	if ch := d.Ch(); d.Err != fail || ch != 9 {
+		t.Errorf("RF_CH %d, error %v", ch, d.Err)
+	}
+}
+
+func TestUnexpected(t *testing.T) {
+	tb := new(fakeTB)
+	m := mock.New(tb)
+	m.ExpectSetReg(5, 1)
+	m.ExpectSetReg(5, 2)
+	m.ExpectCE(0)
+	d := &nrf.Device{Driver: m}
+	d.SetCh(2) // Out of order.
+	if d.Err != mock.ErrUnexpected || len(tb.errs) != 1 ||
+		!strings.Contains(tb.errs[0], "unexpected W_REGISTER RF_CH <- 2") {
+		t.Errorf("error %v, reported: %q", d.Err, tb.errs)
+	}
+	d.Err = nil
+	d.SetCh(1)
+	d.SetCh(2)
+	d.SetCh(3)
+	tb.finish()
+	if len(tb.errs) != 3 || !strings.Contains(tb.errs[1], "RF_CH <- 3") ||
+		!strings.Contains(tb.errs[2], "missing call: SetCE(0)") {
+		t.Errorf("reported: %q", tb.errs)
+	}
+}
+
+func TestWhenCE(t *testing.T) {
+	tb := new(fakeTB)
+	m := mock.New(tb)
+	m.ExpectWriteTxP().WhenCE(0)
+	d := &nrf.Device{Driver: m}
+	m.ExpectCE(1).AnyOrder()
+	d.SetCE(1)
+	d.WriteTxP([]byte{1})
+	tb.finish()
+	if len(tb.errs) != 1 || !strings.Contains(tb.errs[0], "CE=1, want CE=0") {
+		t.Errorf("reported: %q", tb.errs)
+	}
+}
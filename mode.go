@@ -0,0 +1,183 @@
+package nrf
+
+import (
+	"strconv"
+	"time"
+)
+
+// Mode represents operational mode of nRF24L01(+).
+type Mode byte
+
+const (
+	PowerDown Mode = iota
+	StandbyI
+	StandbyII // PTX with CE high and empty Tx FIFO.
+	RX
+	TX
+)
+
+var modeNames = [...]string{"PowerDown", "StandbyI", "StandbyII", "RX", "TX"}
+
+func (m Mode) String() string {
+	if int(m) < len(modeNames) {
+		return modeNames[m]
+	}
+	return "Mode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// Timing parameters from nRF24L01+ datasheet.
+const (
+	Tpd2stby = 1500 * time.Microsecond // Power Down -> Standby (worst case).
+	Tstby2a  = 130 * time.Microsecond  // Standby -> TX/RX settling.
+)
+
+// Clock is used by ModeManager to measure and wait time.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type sysClock struct{}
+
+func (sysClock) Now() time.Time        { return time.Now() }
+func (sysClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// SysClock is Clock that uses time package.
+var SysClock Clock = sysClock{}
+
+// ModeManager switches operational mode of Dev. It tracks mode using values
+// of PwrUp and PrimRx bits written to CONFIG and state of CE line, so Dev
+// shouldn't be used to change them directly. ModeManager enforces Tpd2stby
+// and Tstby2a delays: every transition returns when the new mode is usable.
+type ModeManager struct {
+	Dev   *Device
+	Clock Clock // SysClock is used if nil.
+
+	synced bool
+	cfg    Config
+	ce     bool
+	ready  time.Time // Time when chip leaves Power Down.
+}
+
+func (m *ModeManager) clock() Clock {
+	if m.Clock == nil {
+		return SysClock
+	}
+	return m.Clock
+}
+
+// Sync reads CONFIG and sets CE low. If chip is powered up it is assumed
+// that it did it just now. Sync is called by first mode transition so it
+// needs to be called explicitly only after Dev was used to change CONFIG or
+// CE directly.
+func (m *ModeManager) Sync() error {
+	d := m.Dev
+	m.cfg = d.Config()
+	m.setCE(false)
+	if m.cfg&PwrUp != 0 {
+		m.ready = m.clock().Now().Add(Tpd2stby)
+	}
+	m.synced = d.Err == nil
+	return d.Err
+}
+
+func (m *ModeManager) setCE(v bool) {
+	d := m.Dev
+	if d.Err != nil {
+		return
+	}
+	ce := 0
+	if v {
+		ce = 1
+	}
+	if d.Err = d.SetCE(ce); d.Err == nil {
+		m.ce = v
+	}
+}
+
+func (m *ModeManager) setCfg(cfg Config) {
+	d := m.Dev
+	if cfg == m.cfg {
+		return
+	}
+	d.SetCfg(cfg)
+	if d.Err == nil {
+		m.cfg = cfg
+	}
+}
+
+// Mode returns current mode. It never changes CE line. Before Sync or first
+// transition Mode reads CONFIG and assumes that CE is low. If Dev is PTX
+// with CE high it reads FIFO_STATUS to distinguish TX from StandbyII.
+func (m *ModeManager) Mode() Mode {
+	cfg := m.cfg
+	if !m.synced {
+		cfg = m.Dev.Config()
+	}
+	switch {
+	case cfg&PwrUp == 0:
+		return PowerDown
+	case !m.ce:
+		return StandbyI
+	case cfg&PrimRx != 0:
+		return RX
+	case m.Dev.FIFO()&TxEmpty != 0:
+		return StandbyII
+	}
+	return TX
+}
+
+// PowerDown sets CE low and clears PwrUp bit.
+func (m *ModeManager) PowerDown() error {
+	if !m.synced {
+		m.Sync()
+	}
+	m.setCE(false)
+	m.setCfg(m.cfg &^ PwrUp)
+	return m.Dev.Err
+}
+
+// Standby sets CE low and PwrUp bit. It waits Tpd2stby if chip was in Power
+// Down mode.
+func (m *ModeManager) Standby() error {
+	if !m.synced {
+		m.Sync()
+	}
+	d := m.Dev
+	m.setCE(false)
+	if m.cfg&PwrUp == 0 {
+		m.setCfg(m.cfg | PwrUp)
+		m.ready = m.clock().Now().Add(Tpd2stby)
+	}
+	if d.Err != nil {
+		return d.Err
+	}
+	if t := m.ready.Sub(m.clock().Now()); t > 0 {
+		m.clock().Sleep(t)
+	}
+	return nil
+}
+
+// StartRX switches chip to RX mode through Standby-I and waits Tstby2a.
+func (m *ModeManager) StartRX() error {
+	return m.start(PrimRx)
+}
+
+// StartTX switches chip to PTX through Standby-I and waits Tstby2a. Chip
+// transmits payloads from Tx FIFO (TX mode) or waits for them (Standby-II).
+func (m *ModeManager) StartTX() error {
+	return m.start(0)
+}
+
+func (m *ModeManager) start(primRx Config) error {
+	if err := m.Standby(); err != nil {
+		return err
+	}
+	m.setCfg(m.cfg&^PrimRx | primRx)
+	m.setCE(true)
+	if m.Dev.Err != nil {
+		return m.Dev.Err
+	}
+	m.clock().Sleep(Tstby2a)
+	return nil
+}
@@ -0,0 +1,128 @@
+package nrf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+// fakeClock advances only when Sleep is called.
+type fakeClock struct {
+	now   time.Time
+	slept []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.slept = append(c.slept, d)
+	c.now = c.now.Add(d)
+}
+
+// takeSlept returns and clears sleeps recorded by c.
+func (c *fakeClock) takeSlept() []time.Duration {
+	s := c.slept
+	c.slept = nil
+	return s
+}
+
+func newManager() (*nrf.ModeManager, *emu.Chip, *fakeClock) {
+	chip := emu.NewChip()
+	clk := &fakeClock{now: time.Unix(0, 0)}
+	return &nrf.ModeManager{Dev: &nrf.Device{Driver: chip}, Clock: clk}, chip, clk
+}
+
+func checkMode(t *testing.T, m *nrf.ModeManager, chip *emu.Chip, want nrf.Mode) {
+	t.Helper()
+	if mode := m.Mode(); mode != want {
+		t.Errorf("ModeManager.Mode() = %v, want %v", mode, want)
+	}
+	if mode := chip.Mode(); mode != want {
+		t.Errorf("emulated chip in %v, want %v", mode, want)
+	}
+}
+
+func checkSlept(t *testing.T, clk *fakeClock, want ...time.Duration) {
+	t.Helper()
+	got := clk.takeSlept()
+	if len(got) != len(want) {
+		t.Errorf("slept %v, want %v", got, want)
+		return
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("slept %v, want %v", got, want)
+			return
+		}
+	}
+}
+
+func TestModeTransitions(t *testing.T) {
+	m, chip, clk := newManager()
+	checkMode(t, m, chip, nrf.PowerDown)
+
+	if err := m.StartRX(); err != nil {
+		t.Fatal(err)
+	}
+	checkSlept(t, clk, nrf.Tpd2stby, nrf.Tstby2a)
+	checkMode(t, m, chip, nrf.RX)
+
+	if err := m.Standby(); err != nil {
+		t.Fatal(err)
+	}
+	checkSlept(t, clk)
+	checkMode(t, m, chip, nrf.StandbyI)
+
+	if err := m.StartTX(); err != nil {
+		t.Fatal(err)
+	}
+	checkSlept(t, clk, nrf.Tstby2a)
+	checkMode(t, m, chip, nrf.StandbyII)
+
+	if err := m.PowerDown(); err != nil {
+		t.Fatal(err)
+	}
+	checkSlept(t, clk)
+	checkMode(t, m, chip, nrf.PowerDown)
+
+	if err := m.Standby(); err != nil {
+		t.Fatal(err)
+	}
+	checkSlept(t, clk, nrf.Tpd2stby)
+	checkMode(t, m, chip, nrf.StandbyI)
+}
+
+func TestModeStandbyWaitsRemainingTime(t *testing.T) {
+	m, chip, clk := newManager()
+	d := m.Dev
+	d.SetCfg(nrf.PwrUp)
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	// Sync assumes that chip was powered up just now.
+	if err := m.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	clk.now = clk.now.Add(time.Millisecond)
+	if err := m.Standby(); err != nil {
+		t.Fatal(err)
+	}
+	checkSlept(t, clk, nrf.Tpd2stby-time.Millisecond)
+	checkMode(t, m, chip, nrf.StandbyI)
+}
+
+func TestModeQueryDoesNotTouchCE(t *testing.T) {
+	m, chip, _ := newManager()
+	d := m.Dev
+	d.SetCfg(nrf.PwrUp | nrf.PrimRx)
+	d.SetCE(1)
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	m.Mode()
+	if mode := chip.Mode(); mode != nrf.RX {
+		t.Errorf("Mode() switched chip from RX to %v", mode)
+	}
+}
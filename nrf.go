@@ -1,25 +1,65 @@
 package nrf
 
+import "context"
+
 // Driver contains methods that are need to communicate with nRF24L01(+)
 // transceiver (eg. perform SPI conversation and enable its RF part).
 type Driver interface {
 	// WriteRead perform SPI conversation (see bitbang/spi package).
 	WriteRead(oi ...[]byte) (n int, err error)
-	
+
 	// Set CE line. v==0 sets CE low, v==1 sets CE high, v==2 pulses
 	// CE high for 10 µs and leaves it low.
 	SetCE(v int) error
 }
 
+// IRQWaiter is optional interface that can be implemented by Driver that has
+// access to IRQ line. Device.WaitIRQ uses it to wait for interrupt without
+// polling STATUS register.
+type IRQWaiter interface {
+	// WaitIRQ waits until IRQ line is active (low) or ctx is done.
+	WaitIRQ(ctx context.Context) error
+
+	// IRQ returns true if IRQ line is active.
+	IRQ() (bool, error)
+}
+
+// Batcher is optional interface that can be implemented by Driver that can
+// perform many SPI transactions at once (eg. in one USB transfer). Device.Exec
+// and Radio.Exec use it to execute Batch.
+type Batcher interface {
+	// WriteReadBatch performs transactions in order. Every transaction
+	// contains out/in pairs as arguments of WriteRead and is framed by CSN
+	// separately.
+	WriteReadBatch(txs ...[][]byte) error
+}
+
 // Device wraps driver to provide interface to nRF24L01(+) transceiver.
 type Device struct {
 	Driver
-	
+
 	// Err is error value of last executed command. You can freely call many
 	// command methods before check error. If one command return an error
-	// subsequent commands are not executed.
+	// subsequent commands are not executed. Invalid method arguments are
+	// reported as *ArgError.
 	Err error
-	
+
 	// Status is value of status register read by last executed command.
 	Status
-}
\ No newline at end of file
+
+	// Cache, if not nil, is used by register accessors to avoid reading
+	// registers that chip never changes itself (see RegCache).
+	Cache *RegCache
+}
+
+func (d *Device) radio() Radio {
+	return Radio{d.Driver}
+}
+
+// Radio wraps driver to provide interface to nRF24L01(+) transceiver. Unlike
+// Device it has no state: every method returns value of STATUS register read
+// by executed command and an error, so Radio can be safely used from many
+// call sites.
+type Radio struct {
+	Driver
+}
@@ -1,16 +1,21 @@
 package nrfnet
 
 import (
+	"errors"
+
 	"github.com/ziutek/nrf"
 )
 
+// ErrNotImplemented is returned by functionality that isn't implemented yet.
+var ErrNotImplemented = errors.New("nrfnet: not implemented")
+
 type Interface struct {
 	vpi0, vpi1 uint32
 	vci        [6]byte
-	dev        nrf.Device
+	dev        nrf.Radio
 }
 
-func NewInterface(dev nrf.Device) (*Interface, error) {
+func NewInterface(dev nrf.Radio) (*Interface, error) {
 	if _, err := dev.SetFeature(nrf.DPL); err != nil {
 		return nil, err
 	}
@@ -19,7 +24,7 @@ func NewInterface(dev nrf.Device) (*Interface, error) {
 	}
 	i := new(Interface)
 	i.dev = dev
-	return i, dev
+	return i, nil
 }
 
 // It can be called periodically (pulling) or called by ISR.
@@ -42,7 +47,7 @@ type Addr struct {
 // established. In this case other connections can be rx-only. All connected 
 // VCs must have unique VCIs.
 func (i *Interface) Connect(addr Addr) (Conn, error) {
-
+	return Conn{}, ErrNotImplemented
 }
 
 // ConnectRx establishes rx-only connection to virtual channel (VC) in real RF
@@ -53,5 +58,5 @@ func (i *Interface) Connect(addr Addr) (Conn, error) {
 // 3. If there are two different VPIs used, only one of them can address more
 //    than one VC.
 func (i *Interface) ConnectRx(addr Addr) (Conn, error) {
-
+	return Conn{}, ErrNotImplemented
 }
@@ -0,0 +1,179 @@
+package nrf
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Model identifies chip family detected by Device.Probe.
+type Model byte
+
+const (
+	UnknownModel Model = iota
+	NRF24L01           // nRF24L01 (no 250 kbps, CD instead of RPD).
+	NRF24L01P          // nRF24L01+ or register compatible clone.
+	Beken              // BK2421/BK2423/BK2425 (see Variant.ID).
+	Si24R1             // Si24R1 (3-bit RF_PWR, up to 7 dBm).
+)
+
+var modelNames = [...]string{"Unknown", "nRF24L01", "nRF24L01+", "Beken", "Si24R1"}
+
+func (m Model) String() string {
+	if int(m) < len(modelNames) {
+		return modelNames[m]
+	}
+	return "Model(" + strconv.Itoa(int(m)) + ")"
+}
+
+// Caps describes capabilities of chip.
+type Caps byte
+
+const (
+	CapFeature      Caps = 1 << iota // FEATURE and DYNPD registers are usable.
+	CapNeedActivate                  // FEATURE requires ACTIVATE 0x73.
+	CapDR250k                        // 250 kbps data rate.
+	CapRPD                           // RPD register (CD in Beken, nRF24L01 has CD).
+	CapBank1                         // Beken register bank 1.
+)
+
+func (c Caps) String() string {
+	return flags("Bank1+ RPD+ DR250k+ NeedActivate+ Feature+", 0x1f, byte(c))
+}
+
+// Variant describes chip detected by Device.Probe.
+type Variant struct {
+	Model Model
+	Caps  Caps
+	ID    uint32 // Chip ID read from register bank 1 (Beken only).
+}
+
+func (v Variant) String() string {
+	s := v.Model.String() + " " + v.Caps.String()
+	if v.Caps&CapBank1 != 0 {
+		s += fmt.Sprintf(" ID:%#x", v.ID)
+	}
+	return s
+}
+
+// Check returns error that wraps ErrNotSupported if s uses features not
+// supported by v.
+func (v Variant) Check(s *Settings) error {
+	if s.DataRate == DR250k && v.Caps&CapDR250k == 0 {
+		return fmt.Errorf("%w: 250 kbps", ErrNotSupported)
+	}
+	if v.Caps&CapFeature == 0 {
+		if s.Feature&(DPL|AckPay|DynAck) != 0 {
+			return fmt.Errorf("%w: FEATURE", ErrNotSupported)
+		}
+		for _, p := range s.Pipes {
+			if p.DPL {
+				return fmt.Errorf("%w: DYNPD", ErrNotSupported)
+			}
+		}
+	}
+	return nil
+}
+
+// rbank is STATUS bit that shows selected register bank of Beken chips. It
+// is always 0 in case of nRF24L01(+).
+const rbank Status = 0x80
+
+// Probe detects chip connected to d. It checks presence of chip by writing
+// and reading back RX_ADDR_P2 register (d.Err is set to ErrNoChip if chip
+// isn't detected), detects Beken chips by toggling register bank using
+// ACTIVATE 0x53, checks whether FEATURE register requires ACTIVATE 0x73
+// (FEATURE stays activated after Probe) and whether RF_DR_LOW bit can be set.
+// Si24R1 is recognized by writable bit 0 of RF_SETUP (LSB of its 3-bit
+// RF_PWR, obsolete in nRF24L01+). It is only a heuristic: other nRF24L01+
+// clones without register bank 1 may be reported as Si24R1 or NRF24L01P.
+// Probe restores values of modified registers and leaves Beken chip with bank
+// 0 selected. It should be called in Power Down or Standby-I mode. Probe reads
+// registers from chip even if d.Cache is used.
+func (d *Device) Probe() Variant {
+	var v Variant
+	d.readThrough(func() { v = d.probe() })
+	return v
+}
+
+func (d *Device) probe() Variant {
+	var v Variant
+	if !d.present() {
+		d.check(ErrNoChip)
+		return v
+	}
+	if id, ok := d.bekenID(); ok {
+		v.Model = Beken
+		v.Caps |= CapBank1
+		v.ID = id
+	}
+	f := d.Feature()
+	if d.featureSticks() {
+		v.Caps |= CapFeature
+	} else {
+		d.Activate(0x73)
+		if d.featureSticks() {
+			v.Caps |= CapFeature | CapNeedActivate
+		} else {
+			d.Activate(0x73) // Restore previous state.
+		}
+	}
+	if v.Caps&CapFeature != 0 {
+		d.SetFeature(f)
+	}
+	rf := d.RF()
+	d.SetRF(rf | DRLow)
+	if d.RF()&DRLow != 0 {
+		v.Caps |= CapDR250k
+	}
+	d.SetRF(rf | LNAHC)
+	pwr0 := d.RF()&LNAHC != 0
+	d.SetRF(rf)
+	if v.Model == UnknownModel {
+		switch {
+		case v.Caps&CapDR250k == 0:
+			v.Model = NRF24L01
+		case pwr0:
+			v.Model = Si24R1
+		default:
+			v.Model = NRF24L01P
+		}
+	}
+	if v.Model != NRF24L01 {
+		v.Caps |= CapRPD
+	}
+	if d.Err != nil {
+		return Variant{}
+	}
+	return v
+}
+
+// present reports whether RX_ADDR_P2 register can be written and read back.
+func (d *Device) present() bool {
+	a := d.RxAddr0(2)
+	ok := true
+	for _, b := range []byte{0xa5, 0x5a} {
+		d.SetRxAddr(2, b)
+		ok = ok && d.RxAddr0(2) == b
+	}
+	d.SetRxAddr(2, a)
+	return ok && d.Err == nil
+}
+
+// featureSticks reports whether FEATURE register can be written.
+func (d *Device) featureSticks() bool {
+	all := DPL | AckPay | DynAck
+	d.SetFeature(all)
+	return d.Feature()&all == all
+}
+
+// bekenID toggles register bank and reports whether RBANK bit in STATUS
+// changed. If so it reads chip ID and selects bank 0.
+func (d *Device) bekenID() (uint32, bool) {
+	bank := d.Bank()
+	d.Activate(0x53)
+	if d.Err != nil || d.Bank() == bank {
+		return 0, false
+	}
+	id := d.ChipID()
+	return id, d.Err == nil
+}
@@ -0,0 +1,109 @@
+package nrf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+// hook calls f after every SPI transaction of emulated chip.
+type hook struct {
+	*emu.Chip
+	f func(oi [][]byte)
+}
+
+func (d hook) WriteRead(oi ...[]byte) (int, error) {
+	n, err := d.Chip.WriteRead(oi...)
+	d.f(oi)
+	return n, err
+}
+
+// si24r1 emulates RF_SETUP with writable bit 0.
+func si24r1() nrf.Driver {
+	var bit0 byte
+	return hook{emu.NewChip(), func(oi [][]byte) {
+		switch oi[0][0] {
+		case 0x26:
+			bit0 = oi[2][0] & 1
+		case 0x06:
+			oi[3][0] |= bit0
+		}
+	}}
+}
+
+// nrf24l01 emulates RF_SETUP without RF_DR_LOW bit.
+func nrf24l01() nrf.Driver {
+	return hook{emu.NewChip(), func(oi [][]byte) {
+		if oi[0][0] == 0x06 {
+			oi[3][0] &^= byte(nrf.DRLow)
+		}
+	}}
+}
+
+// noChip emulates SPI bus without chip: MISO is always low.
+type noChip struct{}
+
+func (noChip) WriteRead(oi ...[]byte) (int, error) {
+	n := 0
+	for i := 1; i < len(oi); i += 2 {
+		for k := range oi[i] {
+			oi[i][k] = 0
+		}
+		n += len(oi[i])
+	}
+	return n, nil
+}
+
+func (noChip) SetCE(int) error { return nil }
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		drv   nrf.Driver
+		model nrf.Model
+		caps  nrf.Caps
+	}{
+		{emu.NewChip(), nrf.NRF24L01P, nrf.CapFeature | nrf.CapDR250k | nrf.CapRPD},
+		{si24r1(), nrf.Si24R1, nrf.CapFeature | nrf.CapDR250k | nrf.CapRPD},
+		{nrf24l01(), nrf.NRF24L01, nrf.CapFeature},
+	}
+	for _, tc := range tests {
+		d := &nrf.Device{Driver: tc.drv}
+		d.SetRF(nrf.DR1M.RF() | nrf.Pwr(-6))
+		before := d.Snapshot()
+		v := d.Probe()
+		if d.Err != nil {
+			t.Fatal(d.Err)
+		}
+		if v.Model != tc.model || v.Caps != tc.caps {
+			t.Errorf("Probe: %v, want %v %v", v, tc.model, tc.caps)
+		}
+		if diff := nrf.Diff(before, d.Snapshot()); len(diff) != 0 {
+			t.Errorf("%v: registers not restored: %v", v.Model, diff)
+		}
+	}
+}
+
+func TestProbeNoChip(t *testing.T) {
+	d := &nrf.Device{Driver: noChip{}}
+	if v := d.Probe(); v.Model != nrf.UnknownModel || d.Err != nrf.ErrNoChip {
+		t.Errorf("Probe: %v, %v", v, d.Err)
+	}
+}
+
+func TestVariantCheck(t *testing.T) {
+	v := nrf.Variant{Model: nrf.NRF24L01}
+	s := nrf.Settings{DataRate: nrf.DR250k}
+	if err := v.Check(&s); !errors.Is(err, nrf.ErrNotSupported) {
+		t.Errorf("250 kbps: %v", err)
+	}
+	s = nrf.Settings{Feature: nrf.DPL}
+	if err := v.Check(&s); !errors.Is(err, nrf.ErrNotSupported) {
+		t.Errorf("FEATURE: %v", err)
+	}
+	v.Caps = nrf.CapFeature
+	if err := v.Check(&s); err != nil {
+		t.Errorf("FEATURE supported: %v", err)
+	}
+}
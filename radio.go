@@ -0,0 +1,208 @@
+package nrf
+
+func (r Radio) byteReg(addr byte) (byte, Status, error) {
+	var buf [1]byte
+	s, err := r.Reg(addr, buf[:])
+	return buf[0], s, err
+}
+
+// Config returns value of CONFIG register.
+func (r Radio) Config() (Config, Status, error) {
+	b, s, err := r.byteReg(0)
+	return Config(b), s, err
+}
+
+// SetCfg sets value of CONFIG register.
+func (r Radio) SetCfg(c Config) (Status, error) {
+	return r.SetReg(0, byte(c))
+}
+
+// AA returns value of EN_AA (Enable ‘Auto Acknowledgment’ Function) register.
+func (r Radio) AA() (Pipe, Status, error) {
+	b, s, err := r.byteReg(1)
+	return Pipe(b), s, err
+}
+
+// SetAA sets value of EN_AA (Enable ‘Auto Acknowledgment’ Function) register.
+func (r Radio) SetAA(p Pipe) (Status, error) {
+	return r.SetReg(1, byte(p))
+}
+
+// RxAE returns value of EN_RXADDR (Enabled RX Addresses) register.
+func (r Radio) RxAE() (Pipe, Status, error) {
+	b, s, err := r.byteReg(2)
+	return Pipe(b), s, err
+}
+
+// SetRxAE sets value of EN_RXADDR (Enabled RX Addresses) register.
+func (r Radio) SetRxAE(p Pipe) (Status, error) {
+	return r.SetReg(2, byte(p))
+}
+
+// AW returns value of SETUP_AW (Setup of Address Widths) register increased
+// by two.
+func (r Radio) AW() (int, Status, error) {
+	b, s, err := r.byteReg(3)
+	return int(b) + 2, s, err
+}
+
+// SetALen sets value of SETUP_AW (Setup of Address Widths) register to
+// (alen-2).
+func (r Radio) SetALen(alen int) (Status, error) {
+	if err := checkALen(alen); err != nil {
+		return 0, err
+	}
+	return r.SetReg(3, byte(alen-2))
+}
+
+// Retr returns value of SETUP_RETR (Setup of Automatic Retransmission)
+// register converted to number of retries and delay betwee retries.
+func (r Radio) Retr() (cnt, dlyus int, s Status, err error) {
+	var b byte
+	b, s, err = r.byteReg(4)
+	cnt, dlyus = retr(b)
+	return
+}
+
+// SetRetr sets value of SETUP_RETR (Setup of Automatic Retransmission)
+// register using cnt as number of retries and dlyus as delay betwee retries.
+func (r Radio) SetRetr(cnt, dlyus int) (Status, error) {
+	if err := checkRetr(cnt, dlyus); err != nil {
+		return 0, err
+	}
+	return r.SetReg(4, byte((dlyus/250-1)<<4|cnt))
+}
+
+// Ch returns value of RF_CH (RF Channel) register.
+func (r Radio) Ch() (int, Status, error) {
+	b, s, err := r.byteReg(5)
+	return int(b), s, err
+}
+
+// SetCh sets value of RF_CH (RF Channel) register.
+func (r Radio) SetCh(ch int) (Status, error) {
+	if err := checkCh(ch); err != nil {
+		return 0, err
+	}
+	return r.SetReg(5, byte(ch))
+}
+
+// RF returns value of RF_SETUP register.
+func (r Radio) RF() (RF, Status, error) {
+	b, s, err := r.byteReg(6)
+	return RF(b), s, err
+}
+
+// SetRF sets value of RF_SETUP register.
+func (r Radio) SetRF(rf RF) (Status, error) {
+	return r.SetReg(6, byte(rf))
+}
+
+// Clear clears specified bits in STATUS register.
+func (r Radio) Clear(stat Status) (Status, error) {
+	return r.SetReg(7, byte(stat))
+}
+
+// TxCnt returns values of PLOS and ARC counters from OBSERVE_TX register.
+func (r Radio) TxCnt() (plos, arc int, s Status, err error) {
+	var b byte
+	b, s, err = r.byteReg(8)
+	arc = int(b & 0xf)
+	plos = int(b >> 4)
+	return
+}
+
+// RPD returns value of RPD (Received Power Detector) register (is RP > -64dBm).
+// In case of nRF24L01 it returns value of.CD (Carrier Detect) register.
+func (r Radio) RPD() (bool, Status, error) {
+	b, s, err := r.byteReg(9)
+	return b&1 != 0, s, err
+}
+
+// RxAddr reads address assigned to Rx pipe pn into addr.
+func (r Radio) RxAddr(pn int, addr []byte) (Status, error) {
+	if err := checkPNA(pn, addr); err != nil {
+		return 0, err
+	}
+	return r.Reg(byte(0xa+pn), addr)
+}
+
+// RxAddr0 returns least significant byte of address assigned to Rx pipe pn.
+func (r Radio) RxAddr0(pn int) (byte, Status, error) {
+	if err := checkPN(pn); err != nil {
+		return 0, 0, err
+	}
+	return r.byteReg(byte(0xa + pn))
+}
+
+// SetRxAddr sets address assigned to Rx pipe pn to addr.
+func (r Radio) SetRxAddr(pn int, addr ...byte) (Status, error) {
+	if err := checkPNA(pn, addr); err != nil {
+		return 0, err
+	}
+	return r.SetReg(byte(0xa+pn), addr...)
+}
+
+// TxAddr reads value of TX_ADDR (Transmit address) into addr.
+func (r Radio) TxAddr(addr []byte) (Status, error) {
+	if err := checkAddr(addr); err != nil {
+		return 0, err
+	}
+	return r.Reg(0x10, addr)
+}
+
+// SetTxAddr sets value of TX_ADDR (Transmit address).
+func (r Radio) SetTxAddr(addr ...byte) (Status, error) {
+	if err := checkAddr(addr); err != nil {
+		return 0, err
+	}
+	return r.SetReg(0x10, addr...)
+}
+
+// RxPW returns Rx payload width set for pipe pn.
+func (r Radio) RxPW(pn int) (int, Status, error) {
+	if err := checkPN(pn); err != nil {
+		return 0, 0, err
+	}
+	b, s, err := r.byteReg(byte(0x11 + pn))
+	return int(b) & 0x3f, s, err
+}
+
+// SetRxPW sets Rx payload width for pipe pn.
+func (r Radio) SetRxPW(pn, pw int) (Status, error) {
+	if err := checkPN(pn); err != nil {
+		return 0, err
+	}
+	if err := checkPW(pw); err != nil {
+		return 0, err
+	}
+	return r.SetReg(byte(0x11+pn), byte(pw))
+}
+
+// FIFO returns value of FIFO_STATUS register.
+func (r Radio) FIFO() (FIFO, Status, error) {
+	b, s, err := r.byteReg(0x17)
+	return FIFO(b), s, err
+}
+
+// DynPD returns value of DYNPD (Enable dynamic payload length) register.
+func (r Radio) DynPD() (Pipe, Status, error) {
+	b, s, err := r.byteReg(0x1c)
+	return Pipe(b), s, err
+}
+
+// SetDynPD sets value of DYNPD (Enable dynamic payload length) register.
+func (r Radio) SetDynPD(p Pipe) (Status, error) {
+	return r.SetReg(0x1c, byte(p))
+}
+
+// Feature returns value of FEATURE register.
+func (r Radio) Feature() (Feature, Status, error) {
+	b, s, err := r.byteReg(0x1d)
+	return Feature(b), s, err
+}
+
+// SetFeature sets value of FEATURE register.
+func (r Radio) SetFeature(f Feature) (Status, error) {
+	return r.SetReg(0x1d, byte(f))
+}
@@ -0,0 +1,99 @@
+package nrf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+// spy records commands sent to emulated chip.
+type spy struct {
+	*emu.Chip
+	cmds []byte
+}
+
+func (s *spy) WriteRead(oi ...[]byte) (int, error) {
+	if len(oi) > 0 && len(oi[0]) > 0 {
+		s.cmds = append(s.cmds, oi[0][0])
+	}
+	return s.Chip.WriteRead(oi...)
+}
+
+func TestRadio(t *testing.T) {
+	r := nrf.Radio{Driver: emu.NewChip()}
+	if _, err := r.SetCh(40); err != nil {
+		t.Fatal(err)
+	}
+	ch, s, err := r.Ch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ch != 40 {
+		t.Errorf("Ch() = %d, want 40", ch)
+	}
+	if s.RxPipe() != -1 || s&(nrf.RxDR|nrf.TxDS|nrf.MaxRT) != 0 {
+		t.Errorf("STATUS after reset: %v", s)
+	}
+}
+
+func TestArgError(t *testing.T) {
+	sp := &spy{Chip: emu.NewChip()}
+	r := nrf.Radio{Driver: sp}
+	tests := []struct {
+		name string
+		call func() error
+		want error
+		val  int
+	}{
+		{"SetCh", func() error { _, err := r.SetCh(128); return err }, nrf.ErrChannelRange, 128},
+		{"RxPW", func() error { _, _, err := r.RxPW(6); return err }, nrf.ErrPipeRange, 6},
+		{"SetRxPW", func() error { _, err := r.SetRxPW(0, 33); return err }, nrf.ErrPayloadWidth, 33},
+		{"SetALen", func() error { _, err := r.SetALen(2); return err }, nrf.ErrAddrLen, 2},
+		{"SetTxAddr", func() error { _, err := r.SetTxAddr(1, 2, 3, 4, 5, 6); return err }, nrf.ErrAddrLen, 6},
+		{"SetRetr", func() error { _, err := r.SetRetr(16, 500); return err }, nrf.ErrRetrCount, 16},
+		{"WriteTxP", func() error { _, err := r.WriteTxP(make([]byte, 33)); return err }, nrf.ErrPayloadTooLong, 33},
+		{"WriteAckP", func() error { _, err := r.WriteAckP(-1, nil); return err }, nrf.ErrPipeRange, -1},
+	}
+	for _, tc := range tests {
+		err := tc.call()
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s: error %v, want %v", tc.name, err, tc.want)
+			continue
+		}
+		var ae *nrf.ArgError
+		if !errors.As(err, &ae) || ae.Value != tc.val {
+			t.Errorf("%s: error %#v, want ArgError with value %d", tc.name, err, tc.val)
+		}
+	}
+	if len(sp.cmds) != 0 {
+		t.Errorf("invalid arguments caused SPI transactions: %x", sp.cmds)
+	}
+}
+
+func TestDeviceArgErrorSticky(t *testing.T) {
+	sp := &spy{Chip: emu.NewChip()}
+	d := &nrf.Device{Driver: sp}
+	d.SetCh(200)
+	d.SetCh(5)
+	if !errors.Is(d.Err, nrf.ErrChannelRange) {
+		t.Fatalf("d.Err = %v, want ErrChannelRange", d.Err)
+	}
+	if len(sp.cmds) != 0 {
+		t.Errorf("commands executed after error: %x", sp.cmds)
+	}
+}
+
+func TestWriteTxPNoAckOpcode(t *testing.T) {
+	sp := &spy{Chip: emu.NewChip()}
+	d := &nrf.Device{Driver: sp}
+	d.WriteTxP([]byte{1})
+	d.WriteTxPNoAck([]byte{2})
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	if string(sp.cmds) != "\xa0\xb0" {
+		t.Errorf("commands %x, want a0 b0", sp.cmds)
+	}
+}
@@ -0,0 +1,204 @@
+package nrf
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Packet is payload received by Receiver.
+type Packet struct {
+	Pipe int       // Number of Rx pipe.
+	Data []byte    // Payload.
+	Time time.Time // Time when payload was read from Rx FIFO.
+}
+
+// RxStats contains Receiver counters.
+type RxStats struct {
+	Packets   int // Number of received packets.
+	BadLen    int // Number of payloads with length > 32 (Rx FIFO flushed).
+	BadPipe   int // Number of invalid RX_P_NO values (Rx FIFO flushed).
+	Overflows int // Number of times Rx FIFO was found full.
+}
+
+// Receiver reads payloads from Rx FIFO of Dev configured as powered up PRX and
+// dispatches them to per pipe handlers or sends them to channel. Receiver
+// reads DYNPD, FEATURE and RX_PW registers once, at first use, to learn
+// lengths of static payloads.
+type Receiver struct {
+	Dev *Device
+
+	// Handler[pn], if not nil, is called for every packet received by pipe pn.
+	Handler [6]func(Packet)
+
+	// C, if not nil, is used to send packets from pipes without handler.
+	// Packets from pipes without handler are dropped if C is nil.
+	C chan<- Packet
+
+	// OnTx, if not nil, is called by Run when TxDS or MaxRT flag is set (eg.
+	// after ACK payload was sent). It should clear these flags. If OnTx is
+	// nil Run clears them itself.
+	OnTx func(Status)
+
+	// Poll is STATUS polling interval used if Dev.Driver doesn't implement
+	// IRQWaiter (see Device.WaitIRQ).
+	Poll time.Duration
+
+	mu     sync.Mutex
+	stats  RxStats
+	loaded bool
+	pw     [6]int // Static payload width or -1 if dynamic.
+	b      Batch
+}
+
+// Stats returns current values of counters. It can be called from any
+// goroutine.
+func (r *Receiver) Stats() RxStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+func (r *Receiver) count(f func(s *RxStats)) {
+	r.mu.Lock()
+	f(&r.stats)
+	r.mu.Unlock()
+}
+
+func (r *Receiver) load() {
+	d := r.Dev
+	dpl := d.DynPD()
+	if d.Feature()&DPL == 0 {
+		dpl = 0
+	}
+	for pn := range r.pw {
+		if dpl&(1<<uint(pn)) != 0 {
+			r.pw[pn] = -1
+		} else {
+			r.pw[pn] = d.RxPW(pn)
+		}
+	}
+	r.loaded = d.Err == nil
+}
+
+// Drain reads all payloads from Rx FIFO, clears RxDR flag and dispatches
+// received packets. It can be called directly from interrupt handler. RxDR is
+// always cleared before FIFO_STATUS is read, so payload received after Drain
+// found Rx FIFO empty sets RxDR again and isn't missed.
+func (r *Receiver) Drain(ctx context.Context) error {
+	d := r.Dev
+	if !r.loaded {
+		r.load()
+	}
+	d.Clear(RxDR)
+	fifo := d.FIFO()
+	if d.Err != nil {
+		return d.Err
+	}
+	if fifo&RxFull != 0 {
+		r.count(func(s *RxStats) { s.Overflows++ })
+	}
+	for fifo&RxEmpty == 0 {
+		// STATUS read by R_RX_PL_WID contains pipe number.
+		plen := d.RxPLen()
+		pn := d.RxPipe()
+		if d.Err != nil {
+			return d.Err
+		}
+		badPipe := uint(pn) >= uint(len(r.pw))
+		if !badPipe && r.pw[pn] >= 0 {
+			plen = r.pw[pn]
+		}
+		if badPipe || plen > 32 {
+			// RX_P_NO inconsistent with FIFO_STATUS, 110 (unused) or
+			// corrupted payload length.
+			d.FlushRx()
+			d.Clear(RxDR)
+			fifo = d.FIFO()
+			r.count(func(s *RxStats) {
+				if badPipe {
+					s.BadPipe++
+				} else {
+					s.BadLen++
+				}
+			})
+			continue
+		}
+		// Read payload, clear RxDR and read FIFO_STATUS in one batch.
+		b := &r.b
+		b.Reset()
+		pay := b.ReadRxP(plen)
+		b.Clear(RxDR)
+		fs := b.Reg(0x17, 1)
+		d.Exec(b)
+		if d.Err != nil {
+			return d.Err
+		}
+		fifo = FIFO(fs.Byte())
+		r.count(func(s *RxStats) { s.Packets++ })
+		if err := r.dispatch(ctx, Packet{pn, pay.Val, time.Now()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Receiver) dispatch(ctx context.Context, p Packet) error {
+	if uint(p.Pipe) < uint(len(r.Handler)) && r.Handler[p.Pipe] != nil {
+		r.Handler[p.Pipe](p)
+		return nil
+	}
+	if r.C == nil {
+		return nil
+	}
+	select {
+	case r.C <- p:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run sets CE high and receives packets until ctx is done or an error
+// occurs. It sets CE low before return.
+func (r *Receiver) Run(ctx context.Context) error {
+	d := r.Dev
+	if d.Err != nil {
+		return d.Err
+	}
+	if d.Err = d.SetCE(1); d.Err != nil {
+		return d.Err
+	}
+	err := r.run(ctx)
+	if d.Err == nil {
+		d.Err = d.SetCE(0)
+	}
+	if d.Err != nil {
+		return d.Err
+	}
+	return err
+}
+
+func (r *Receiver) run(ctx context.Context) error {
+	d := r.Dev
+	for {
+		if err := d.WaitIRQ(ctx, r.Poll); err != nil {
+			return err
+		}
+		if s := d.Status & (TxDS | MaxRT); s != 0 {
+			if r.OnTx != nil {
+				r.OnTx(d.Status)
+			} else {
+				d.Clear(s)
+			}
+		}
+		if d.Status&RxDR != 0 {
+			if err := r.Drain(ctx); err != nil {
+				return err
+			}
+		}
+		if d.Err != nil {
+			return d.Err
+		}
+	}
+}
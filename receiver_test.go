@@ -0,0 +1,139 @@
+package nrf_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+// sendTo transmits pay from PTX a to address addr and waits for ACK.
+func sendTo(t *testing.T, a *nrf.Device, addr []byte, pay []byte) {
+	t.Helper()
+	a.SetTxAddr(addr...)
+	a.SetRxAddr(0, addr...)
+	a.WriteTxP(pay)
+	if a.Err != nil {
+		t.Fatal(a.Err)
+	}
+	if err := a.SetCE(2); err != nil {
+		t.Fatal(err)
+	}
+	a.NOP()
+	if a.Status&nrf.TxDS == 0 {
+		t.Fatalf("payload %q not acknowledged: %v", pay, a.Status)
+	}
+	a.Clear(nrf.TxDS)
+	if a.Err != nil {
+		t.Fatal(a.Err)
+	}
+}
+
+var (
+	addr0 = []byte{1, 2, 3, 4, 5}
+	addr1 = []byte{6, 6, 6, 6, 6}
+)
+
+func TestReceiverDrain(t *testing.T) {
+	a, b := link(t, 3)
+	if err := b.SetCE(1); err != nil {
+		t.Fatal(err)
+	}
+	sendTo(t, a, addr0, []byte("p0 a"))
+	sendTo(t, a, addr1, []byte("p1"))
+	sendTo(t, a, addr0, []byte("p0 b"))
+
+	c := make(chan nrf.Packet, 3)
+	var p1 []string
+	r := &nrf.Receiver{Dev: b, C: c}
+	r.Handler[1] = func(p nrf.Packet) { p1 = append(p1, string(p.Data)) }
+	if err := r.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(p1) != 1 || p1[0] != "p1" {
+		t.Errorf("pipe 1 handler received %q", p1)
+	}
+	close(c)
+	var p0 []string
+	for p := range c {
+		if p.Pipe != 0 {
+			t.Errorf("packet from pipe %d sent to channel", p.Pipe)
+		}
+		p0 = append(p0, string(p.Data))
+	}
+	if len(p0) != 2 || p0[0] != "p0 a" || p0[1] != "p0 b" {
+		t.Errorf("channel received %q", p0)
+	}
+	if s := r.Stats(); s.Packets != 3 || s.Overflows != 1 {
+		t.Errorf("stats: %+v", s)
+	}
+	b.NOP()
+	if b.Status&nrf.RxDR != 0 || b.FIFO()&nrf.RxEmpty == 0 {
+		t.Errorf("after Drain: %v %v", b.Status, b.FIFO())
+	}
+}
+
+// badPipe reports RX_P_NO=110 in every STATUS byte.
+type badPipe struct {
+	*emu.Chip
+}
+
+func (d badPipe) WriteRead(oi ...[]byte) (int, error) {
+	n, err := d.Chip.WriteRead(oi...)
+	if len(oi) > 1 && len(oi[1]) > 0 {
+		oi[1][0] = oi[1][0]&^0x0e | 0x0c
+	}
+	return n, err
+}
+
+func TestReceiverBadPipe(t *testing.T) {
+	a, b := link(t, 3)
+	if err := b.SetCE(1); err != nil {
+		t.Fatal(err)
+	}
+	sendTo(t, a, addr0, []byte("x"))
+	b.Driver = badPipe{b.Driver.(*emu.Chip)}
+	r := &nrf.Receiver{Dev: b}
+	if err := r.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s := r.Stats(); s.BadPipe != 1 || s.Packets != 0 {
+		t.Errorf("stats: %+v", s)
+	}
+	if b.FIFO()&nrf.RxEmpty == 0 {
+		t.Error("Rx FIFO not flushed")
+	}
+}
+
+func TestReceiverRun(t *testing.T) {
+	a, b := link(t, 3)
+	c := make(chan nrf.Packet)
+	r := &nrf.Receiver{Dev: b, C: c, Poll: 50 * time.Microsecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- r.Run(ctx) }()
+	for i := 0; i < 3; i++ {
+		// Wait until Run sets CE high.
+		for !b.Driver.(*emu.Chip).CE() {
+			time.Sleep(100 * time.Microsecond)
+		}
+		sendTo(t, a, addr0, []byte{byte(i)})
+		select {
+		case p := <-c:
+			if len(p.Data) != 1 || p.Data[0] != byte(i) {
+				t.Errorf("received %x, want %02x", p.Data, i)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("packet not received")
+		}
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run returned %v, want context.Canceled", err)
+	}
+	if b.Driver.(*emu.Chip).CE() {
+		t.Error("CE left high")
+	}
+}
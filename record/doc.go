@@ -0,0 +1,28 @@
+// Package record provides nrf.Driver that records SPI transactions and CE
+// changes of other Driver to a file and Player that replays such recording,
+// so session captured once with real hardware can be used in regression
+// tests.
+//
+// Recording is a text file. First line contains format version and optional
+// capabilities of recorded driver:
+//
+//	nrfrec 1 irq
+//
+// Every next line describes one driver call: time since start of recording
+// in nanoseconds, call type, its arguments and results. Optional error
+// returned by the call is appended after '!':
+//
+//	1520 W 2 200e 0e00
+//	3100 W 3 61 0e - 0102
+//	3300 CE 1
+//	4000 WAIT ! context canceled
+//	4100 IRQ 1
+//
+// First line above describes WriteRead(out, in) call that returned n=2, the
+// second one WriteRead(out, in, nil, in), the last one IRQ() that returned
+// true.
+//
+// WriteRead slices are hex encoded ('-' means empty slice). Values of in
+// slices are the ones read from chip. Every transaction of WriteReadBatch is
+// recorded as separate W line. Lines that start with '#' are comments.
+package record
@@ -0,0 +1,321 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ziutek/nrf"
+)
+
+type event struct {
+	line int
+	typ  string   // W, CE, WAIT, IRQ.
+	n    int      // Result of WriteRead, argument of SetCE, result of IRQ.
+	oi   [][]byte // Arguments of WriteRead.
+	err  string
+}
+
+func (e *event) String() string {
+	var s string
+	switch e.typ {
+	case "W":
+		s = "W"
+		for _, b := range e.oi {
+			s += " " + encode(b)
+		}
+		s += "  " + nrf.Decode(nrf.Flatten(e.oi))
+	case "WAIT":
+		s = "WaitIRQ"
+	case "IRQ":
+		s = "IRQ"
+	default:
+		s = fmt.Sprint(e.typ, " ", e.n)
+	}
+	return s
+}
+
+func parseEvent(line string, num int) (*event, error) {
+	e := &event{line: num}
+	if i := strings.Index(line, " ! "); i >= 0 {
+		e.err = line[i+3:]
+		line = line[:i]
+	}
+	f := strings.Fields(line)
+	if len(f) < 2 {
+		return nil, fmt.Errorf("record: line %d: too few fields", num)
+	}
+	if _, err := strconv.ParseInt(f[0], 10, 64); err != nil {
+		return nil, fmt.Errorf("record: line %d: bad time: %v", num, err)
+	}
+	e.typ = f[1]
+	args := f[2:]
+	var err error
+	switch e.typ {
+	case "W":
+		if len(args) < 1 {
+			return nil, fmt.Errorf("record: line %d: too few fields", num)
+		}
+		if e.n, err = strconv.Atoi(args[0]); err != nil {
+			break
+		}
+		for _, a := range args[1:] {
+			var b []byte
+			if a != "-" {
+				if b, err = hex.DecodeString(a); err != nil {
+					break
+				}
+			}
+			e.oi = append(e.oi, b)
+		}
+	case "CE", "IRQ":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("record: line %d: bad number of fields", num)
+		}
+		e.n, err = strconv.Atoi(args[0])
+	case "WAIT":
+	default:
+		return nil, fmt.Errorf("record: line %d: unknown call %s", num, e.typ)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("record: line %d: %v", num, err)
+	}
+	return e, nil
+}
+
+// Mismatch is returned by Player if host performs different call or sends
+// different data than recorded.
+type Mismatch struct {
+	Line int    // Line of recording that contains expected call.
+	Want string // Expected call.
+	Got  string // Performed call.
+}
+
+func (m *Mismatch) Error() string {
+	return fmt.Sprintf("record: line %d: unexpected call:\n- %s\n+ %s", m.Line, m.Want, m.Got)
+}
+
+// ErrEnd is returned by Player if host performs more calls than recorded.
+var ErrEnd = errors.New("record: end of recording")
+
+// Player replays recording: it checks that host performs the same calls and
+// sends the same data as recorded and returns recorded data and errors.
+// Time of calls isn't checked. After first mismatch Player returns the same
+// error for all next calls.
+type Player struct {
+	// If TB is not nil, first mismatch is also reported using TB.Errorf.
+	TB testing.TB
+
+	mu     sync.Mutex
+	events []*event
+	next   int
+	irq    bool
+	err    error
+}
+
+// Load reads recording from r.
+func Load(r io.Reader) (*Player, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	if !sc.Scan() {
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("record: empty recording")
+	}
+	hdr := strings.Fields(sc.Text())
+	if len(hdr) < 2 || hdr[0] != "nrfrec" {
+		return nil, errors.New("record: not a recording")
+	}
+	if v, err := strconv.Atoi(hdr[1]); err != nil || v != Version {
+		return nil, fmt.Errorf("record: unsupported version %s", hdr[1])
+	}
+	p := new(Player)
+	for _, c := range hdr[2:] {
+		if c == "irq" {
+			p.irq = true
+		}
+	}
+	for num := 2; sc.Scan(); num++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		e, err := parseEvent(line, num)
+		if err != nil {
+			return nil, err
+		}
+		p.events = append(p.events, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Open reads recording from named file.
+func Open(name string) (*Player, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// Driver returns Driver that replays recording. It implements IRQWaiter if
+// recorded driver implemented it. It always implements Batcher.
+func (p *Player) Driver() nrf.Driver {
+	if p.irq {
+		return irqPlayer{p}
+	}
+	return p
+}
+
+// Err returns first mismatch or error if not all recorded calls were
+// performed. It should be called at end of test.
+func (p *Player) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	if n := len(p.events) - p.next; n > 0 {
+		return fmt.Errorf("record: %d recorded calls not performed, first at line %d: %s", n, p.events[p.next].line, p.events[p.next])
+	}
+	return nil
+}
+
+func (p *Player) fail(err error) error {
+	if p.err == nil {
+		p.err = err
+		if p.TB != nil {
+			p.TB.Helper()
+			p.TB.Errorf("%v", err)
+		}
+	}
+	return p.err
+}
+
+// call returns next event if it matches got.
+func (p *Player) call(got *event) (*event, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.next >= len(p.events) {
+		return nil, p.fail(fmt.Errorf("%w: unexpected call: %s", ErrEnd, got))
+	}
+	e := p.events[p.next]
+	if !match(e, got) {
+		return nil, p.fail(&Mismatch{Line: e.line, Want: e.String(), Got: got.String()})
+	}
+	p.next++
+	return e, nil
+}
+
+func match(e, got *event) bool {
+	if e.typ != got.typ || len(e.oi) != len(got.oi) {
+		return false
+	}
+	if e.typ == "CE" && e.n != got.n {
+		return false
+	}
+	for i, b := range got.oi {
+		if i&1 == 0 {
+			if !bytes.Equal(b, e.oi[i]) {
+				return false
+			}
+		} else if len(b) != len(e.oi[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// recErr returns error recorded in e.
+func recErr(e *event) error {
+	switch e.err {
+	case "":
+		return nil
+	case context.Canceled.Error():
+		return context.Canceled
+	case context.DeadlineExceeded.Error():
+		return context.DeadlineExceeded
+	}
+	return errors.New(e.err)
+}
+
+func (p *Player) WriteRead(oi ...[]byte) (n int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	got := &event{typ: "W", oi: append([][]byte(nil), oi...)}
+	if p.next < len(p.events) {
+		// Use recorded MISO data to describe mismatch.
+		rec := p.events[p.next].oi
+		for i := 1; i < len(oi) && i < len(rec); i += 2 {
+			if len(rec[i]) == len(oi[i]) {
+				got.oi[i] = rec[i]
+			}
+		}
+	}
+	e, err := p.call(got)
+	if err != nil {
+		return 0, err
+	}
+	for i := 1; i < len(oi); i += 2 {
+		copy(oi[i], e.oi[i])
+	}
+	return e.n, recErr(e)
+}
+
+func (p *Player) WriteReadBatch(txs ...[][]byte) error {
+	for _, oi := range txs {
+		if _, err := p.WriteRead(oi...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Player) SetCE(v int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, err := p.call(&event{typ: "CE", n: v})
+	if err != nil {
+		return err
+	}
+	return recErr(e)
+}
+
+type irqPlayer struct {
+	*Player
+}
+
+func (p irqPlayer) WaitIRQ(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, err := p.call(&event{typ: "WAIT"})
+	if err != nil {
+		return err
+	}
+	return recErr(e)
+}
+
+func (p irqPlayer) IRQ() (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e, err := p.call(&event{typ: "IRQ"})
+	if err != nil {
+		return false, err
+	}
+	return e.n != 0, recErr(e)
+}
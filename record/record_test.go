@@ -0,0 +1,122 @@
+package record_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+	"github.com/ziutek/nrf/record"
+)
+
+// session performs some operations on d and returns values read from it.
+func session(d *nrf.Device, ch int) []byte {
+	d.SetCfg(nrf.PwrUp)
+	d.SetCh(ch)
+	d.WriteTxP([]byte{1, 2, 3})
+	d.SetCE(2)
+	d.WaitIRQ(context.Background(), 0)
+	var b nrf.Batch
+	b.Clear(nrf.MaxRT)
+	fifo := b.Reg(0x17, 1)
+	d.Exec(&b)
+	return []byte{byte(d.Ch()), byte(d.Status), fifo.Byte()}
+}
+
+func record1(t *testing.T) string {
+	t.Helper()
+	var buf bytes.Buffer
+	r := &record.Recorder{W: &buf}
+	d := &nrf.Device{Driver: r.Wrap(emu.NewChip())}
+	session(d, 7)
+	if d.Err != nil || r.Err() != nil {
+		t.Fatal(d.Err, r.Err())
+	}
+	return buf.String()
+}
+
+func TestRoundTrip(t *testing.T) {
+	rec := record1(t)
+	if !strings.HasPrefix(rec, "nrfrec 1 irq\n") || !strings.Contains(rec, " WAIT\n") {
+		t.Fatalf("recording:\n%s", rec)
+	}
+	want := session(&nrf.Device{Driver: emu.NewChip()}, 7)
+	p, err := record.Load(strings.NewReader(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.TB = t
+	d := &nrf.Device{Driver: p.Driver()}
+	if _, ok := d.Driver.(nrf.IRQWaiter); !ok {
+		t.Error("IRQWaiter not implemented by Player.Driver")
+	}
+	got := session(d, 7)
+	if d.Err != nil || p.Err() != nil {
+		t.Fatal(d.Err, p.Err())
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("replayed %x, want %x", got, want)
+	}
+}
+
+func TestMismatch(t *testing.T) {
+	p, err := record.Load(strings.NewReader(record1(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &nrf.Device{Driver: p.Driver()}
+	session(d, 8)
+	var m *record.Mismatch
+	if !errors.As(d.Err, &m) || m.Line != 3 {
+		t.Fatalf("error %v, want mismatch at line 3", d.Err)
+	}
+	if !strings.Contains(m.Want, "RF_CH <- 7") || !strings.Contains(m.Got, "RF_CH <- 8") {
+		t.Errorf("mismatch: %+v", m)
+	}
+	if p.Err() != m {
+		t.Errorf("Err: %v", p.Err())
+	}
+
+	p, _ = record.Load(strings.NewReader(record1(t)))
+	d = &nrf.Device{Driver: p.Driver()}
+	session(d, 7)
+	d.NOP()
+	if !errors.Is(d.Err, record.ErrEnd) {
+		t.Errorf("error %v, want ErrEnd", d.Err)
+	}
+
+	p, _ = record.Load(strings.NewReader(record1(t)))
+	d = &nrf.Device{Driver: p.Driver()}
+	d.SetCfg(nrf.PwrUp)
+	if err := p.Err(); err == nil || !strings.Contains(err.Error(), "not performed") {
+		t.Errorf("Err after incomplete replay: %v", err)
+	}
+}
+
+// failing returns err from every WriteRead call.
+type failing struct {
+	*emu.Chip
+	err error
+}
+
+func (d failing) WriteRead(oi ...[]byte) (int, error) { return 0, d.err }
+
+func TestRecordedError(t *testing.T) {
+	var buf bytes.Buffer
+	r := &record.Recorder{W: &buf}
+	drv := failing{emu.NewChip(), errors.New("SPI failure")}
+	d := &nrf.Device{Driver: r.Wrap(drv)}
+	d.SetCh(1)
+	p, err := record.Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d = &nrf.Device{Driver: p.Driver()}
+	d.SetCh(1)
+	if d.Err == nil || d.Err.Error() != drv.err.Error() || p.Err() != nil {
+		t.Errorf("replayed error %v, want %v (%v)", d.Err, drv.err, p.Err())
+	}
+}
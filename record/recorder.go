@@ -0,0 +1,149 @@
+package record
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ziutek/nrf"
+)
+
+// Version is version of recording format written by Recorder.
+const Version = 1
+
+// Recorder writes recording of calls of Driver returned by Wrap to W. One
+// Recorder should be used to wrap one Driver.
+type Recorder struct {
+	W io.Writer
+
+	mu    sync.Mutex
+	start time.Time
+	err   error
+}
+
+// Wrap writes header of recording to r.W and returns Driver that calls drv
+// and records its calls. Returned Driver implements IRQWaiter if drv
+// implements it. It always implements Batcher.
+func (r *Recorder) Wrap(drv nrf.Driver) nrf.Driver {
+	rd := &recDriver{Driver: drv, r: r}
+	w, irq := drv.(nrf.IRQWaiter)
+	hdr := fmt.Sprintf("nrfrec %d", Version)
+	if irq {
+		hdr += " irq"
+	}
+	r.mu.Lock()
+	r.start = time.Now()
+	r.write(hdr)
+	r.mu.Unlock()
+	if irq {
+		return &recIRQDriver{rd, w}
+	}
+	return rd
+}
+
+// Err returns first error returned by r.W.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) write(line string) {
+	if r.err == nil {
+		_, r.err = io.WriteString(r.W, line+"\n")
+	}
+}
+
+func (r *Recorder) record(start time.Time, line string, err error) {
+	if err != nil {
+		line += " ! " + strings.ReplaceAll(err.Error(), "\n", " ")
+	}
+	r.mu.Lock()
+	r.write(fmt.Sprint(start.Sub(r.start).Nanoseconds(), " ", line))
+	r.mu.Unlock()
+}
+
+func encode(b []byte) string {
+	if len(b) == 0 {
+		return "-"
+	}
+	return hex.EncodeToString(b)
+}
+
+func writeLine(n int, oi [][]byte) string {
+	s := fmt.Sprint("W ", n)
+	for _, b := range oi {
+		s += " " + encode(b)
+	}
+	return s
+}
+
+type recDriver struct {
+	nrf.Driver
+	r *Recorder
+}
+
+func (d *recDriver) WriteRead(oi ...[]byte) (n int, err error) {
+	start := time.Now()
+	n, err = d.Driver.WriteRead(oi...)
+	d.r.record(start, writeLine(n, oi), err)
+	return n, err
+}
+
+// WriteReadBatch records error returned by batch driver in line of last
+// transaction.
+func (d *recDriver) WriteReadBatch(txs ...[][]byte) error {
+	b, ok := d.Driver.(nrf.Batcher)
+	if !ok {
+		for _, oi := range txs {
+			if _, err := d.WriteRead(oi...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	start := time.Now()
+	err := b.WriteReadBatch(txs...)
+	for i, oi := range txs {
+		var e error
+		if i == len(txs)-1 {
+			e = err
+		}
+		d.r.record(start, writeLine(0, oi), e)
+	}
+	return err
+}
+
+func (d *recDriver) SetCE(v int) error {
+	start := time.Now()
+	err := d.Driver.SetCE(v)
+	d.r.record(start, fmt.Sprint("CE ", v), err)
+	return err
+}
+
+type recIRQDriver struct {
+	*recDriver
+	w nrf.IRQWaiter
+}
+
+func (d *recIRQDriver) WaitIRQ(ctx context.Context) error {
+	start := time.Now()
+	err := d.w.WaitIRQ(ctx)
+	d.r.record(start, "WAIT", err)
+	return err
+}
+
+func (d *recIRQDriver) IRQ() (bool, error) {
+	start := time.Now()
+	irq, err := d.w.IRQ()
+	v := 0
+	if irq {
+		v = 1
+	}
+	d.r.record(start, fmt.Sprint("IRQ ", v), err)
+	return irq, err
+}
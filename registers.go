@@ -130,16 +130,25 @@ func (d *Device) AW() int {
 
 // SetAW sets value of SETUP_AW (Setup of Address Widths) register to (alen-2).
 func (d *Device) SetALen(alen int) {
+	if d.check(checkALen(alen)) {
+		d.SetReg(3, byte(alen-2))
+	}
+}
+
+func checkALen(alen int) error {
 	if alen < 3 || alen > 5 {
-		panic("alen<3 || alen>5")
+		return argErr(ErrAddrLen, alen)
 	}
-	d.SetReg(3, byte(alen-2))
+	return nil
 }
 
 // Retr returns value of SETUP_RETR (Setup of Automatic Retransmission)
 // register converted to number of retries and delay betwee retries.
 func (d *Device) Retr() (cnt, dlyus int) {
-	b := d.byteReg(4)
+	return retr(d.byteReg(4))
+}
+
+func retr(b byte) (cnt, dlyus int) {
 	cnt = int(b & 0xf)
 	dlyus = (int(b>>4) + 1) * 250
 	return
@@ -148,13 +157,19 @@ func (d *Device) Retr() (cnt, dlyus int) {
 // SetRetr sets value of SETUP_RETR (Setup of Automatic Retransmission)
 // register using cnt as number of retries and dlyus as delay betwee retries.
 func (d *Device) SetRetr(cnt, dlyus int) {
+	if d.check(checkRetr(cnt, dlyus)) {
+		d.SetReg(4, byte((dlyus/250-1)<<4|cnt))
+	}
+}
+
+func checkRetr(cnt, dlyus int) error {
 	if uint(cnt) > 15 {
-		panic("cnt<0 || cnt>15")
+		return argErr(ErrRetrCount, cnt)
 	}
 	if dlyus < 250 || dlyus > 4000 {
-		panic("dlyus<250 || dlyus>4000")
+		return argErr(ErrRetrDelay, dlyus)
 	}
-	d.SetReg(4, byte((dlyus/250-1)<<4|cnt))
+	return nil
 }
 
 // Ch returns value of RF_CH (RF Channel) register.
@@ -164,10 +179,16 @@ func (d *Device) Ch() int {
 
 // SetCh sets value of RF_CH (RF Channel) register.
 func (d *Device) SetCh(ch int) {
+	if d.check(checkCh(ch)) {
+		d.SetReg(5, byte(ch))
+	}
+}
+
+func checkCh(ch int) error {
 	if uint(ch) > 127 {
-		panic("ch<0 || ch>127")
+		return argErr(ErrChannelRange, ch)
 	}
-	d.SetReg(5, byte(ch))
+	return nil
 }
 
 type RF byte
@@ -203,6 +224,49 @@ func (rf RF) String() string {
 		strconv.Itoa(rf.Pwr()) + "dBm"
 }
 
+// DataRate returns data rate set in rf.
+func (rf RF) DataRate() DataRate {
+	switch {
+	case rf&DRLow != 0:
+		return DR250k
+	case rf&DRHigh != 0:
+		return DR2M
+	}
+	return DR1M
+}
+
+// DataRate represents RF data rate.
+type DataRate byte
+
+const (
+	DR1M   DataRate = iota // 1 Mbps
+	DR2M                   // 2 Mbps
+	DR250k                 // 250 kbps
+)
+
+// RF returns RF_SETUP bits that select dr.
+func (dr DataRate) RF() RF {
+	switch dr {
+	case DR2M:
+		return DRHigh
+	case DR250k:
+		return DRLow
+	}
+	return 0
+}
+
+func (dr DataRate) String() string {
+	switch dr {
+	case DR1M:
+		return "1Mbps"
+	case DR2M:
+		return "2Mbps"
+	case DR250k:
+		return "250kbps"
+	}
+	return "DataRate(" + strconv.Itoa(int(dr)) + ")"
+}
+
 // RF returns value of RF_SETUP register.
 func (d *Device) RF() RF {
 	return RF(d.byteReg(6))
@@ -232,69 +296,89 @@ func (d *Device) RPD() bool {
 	return d.byteReg(9)&1 != 0
 }
 
-func checkPN(pn int) {
+func checkPN(pn int) error {
 	if uint(pn) > 5 {
-		panic("pn<0 || pn>5")
+		return argErr(ErrPipeRange, pn)
 	}
+	return nil
 }
 
-func checkAddr(addr []byte) {
+func checkAddr(addr []byte) error {
 	if len(addr) > 5 {
-		panic("len(addr)>5")
+		return argErr(ErrAddrLen, len(addr))
 	}
+	return nil
 }
 
-func checkPNA(pn int, addr []byte) {
-	checkPN(pn)
-	checkAddr(addr)
+func checkPNA(pn int, addr []byte) error {
+	if err := checkPN(pn); err != nil {
+		return err
+	}
+	if err := checkAddr(addr); err != nil {
+		return err
+	}
 	if pn > 1 && len(addr) > 1 {
-		panic("pn>1 && len(addr)>1")
+		return argErr(ErrAddrLen, len(addr))
 	}
+	return nil
 }
 
 // RxAddr reads address assigned to Rx pipe pn into addr.
 func (d *Device) RxAddr(pn int, addr []byte) {
-	checkPNA(pn, addr)
-	d.Reg(byte(0xa+pn), addr)
+	if d.check(checkPNA(pn, addr)) {
+		d.Reg(byte(0xa+pn), addr)
+	}
 }
 
 // RxAddr0 returns least significant byte of address assigned to Rx pipe pn.
 func (d *Device) RxAddr0(pn int) byte {
-	checkPN(pn)
+	if !d.check(checkPN(pn)) {
+		return 0
+	}
 	return d.byteReg(byte(0xa + pn))
 }
 
 // SetRxAddr sets address assigned to Rx pipe pn to addr.
 func (d *Device) SetRxAddr(pn int, addr ...byte) {
-	checkPNA(pn, addr)
-	d.SetReg(byte(0xa+pn), addr...)
+	if d.check(checkPNA(pn, addr)) {
+		d.SetReg(byte(0xa+pn), addr...)
+	}
 }
 
 // TxAddr reads value of TX_ADDR (Transmit address) into addr.
 func (d *Device) TxAddr(addr []byte) {
-	checkAddr(addr)
-	d.Reg(0x10, addr)
+	if d.check(checkAddr(addr)) {
+		d.Reg(0x10, addr)
+	}
 }
 
 // SetTxAddr sets value of TX_ADDR (Transmit address).
 func (d *Device) SetTxAddr(addr ...byte) {
-	checkAddr(addr)
-	d.SetReg(0x10, addr...)
+	if d.check(checkAddr(addr)) {
+		d.SetReg(0x10, addr...)
+	}
 }
 
 // RxPW returns Rx payload width set for pipe pn.
 func (d *Device) RxPW(pn int) int {
-	checkPN(pn)
+	if !d.check(checkPN(pn)) {
+		return 0
+	}
 	return int(d.byteReg(byte(0x11+pn))) & 0x3f
 }
 
 // SetRxPW sets Rx payload width for pipe pn.
 func (d *Device) SetRxPW(pn, pw int) {
-	checkPN(pn)
+	if d.check(checkPN(pn)) && d.check(checkPW(pw)) {
+		d.SetReg(byte(0x11+pn), byte(pw))
+	}
+}
+
+func checkPW(pw int) error {
 	if uint(pw) > 32 {
-		panic("pw<0 || pw>32")
+		return argErr(ErrPayloadWidth, pw)
 	}
-	d.SetReg(byte(0x11+pn), byte(pw))
+	return nil
 }
 
 type FIFO byte
@@ -0,0 +1,205 @@
+package nrf
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// NumCh is number of RF channels that can be scanned by Scanner.
+const NumCh = 126
+
+// MinDwell is minimal time that chip must spend in RX mode to set RPD: Rx
+// settling time (130 µs) plus RPD measurement time (40 µs).
+const MinDwell = 170 * time.Microsecond
+
+// Spectrum contains occupancy (fraction of RPD samples that were set) of
+// every RF channel.
+type Spectrum [NumCh]float64
+
+const ramp = " .:-=+*#%@"
+
+// String returns s as one row of ASCII waterfall: one character per channel.
+func (s *Spectrum) String() string {
+	var row [NumCh]byte
+	for ch, v := range s {
+		i := int(v*float64(len(ramp)-1) + 0.5)
+		if i < 0 {
+			i = 0
+		} else if i >= len(ramp) {
+			i = len(ramp) - 1
+		}
+		row[ch] = ramp[i]
+	}
+	return string(row[:])
+}
+
+// WaterfallHeader returns two lines that describe columns of ASCII waterfall:
+// tens and units of channel numbers.
+func WaterfallHeader() string {
+	var buf bytes.Buffer
+	for ch := 0; ch < NumCh; ch++ {
+		if ch%10 == 0 {
+			buf.WriteByte('0' + byte(ch/10%10))
+		} else {
+			buf.WriteByte(' ')
+		}
+	}
+	buf.WriteByte('\n')
+	for ch := 0; ch < NumCh; ch++ {
+		buf.WriteByte('0' + byte(ch%10))
+	}
+	return buf.String()
+}
+
+// WiFi returns mean occupancy of nRF channels overlapped by Wi-Fi channels
+// 1..14 (WiFi()[0] corresponds to Wi-Fi channel 1).
+func (s *Spectrum) WiFi() [14]float64 {
+	var w [14]float64
+	for i := range w {
+		var n int
+		for ch := range s {
+			if wifiOverlap(i+1, ch) {
+				w[i] += s[ch]
+				n++
+			}
+		}
+		if n != 0 {
+			w[i] /= float64(n)
+		}
+	}
+	return w
+}
+
+// WiFiCenter returns center frequency [MHz] of 2.4 GHz Wi-Fi channel wch.
+func WiFiCenter(wch int) int {
+	if wch == 14 {
+		return 2484
+	}
+	return 2407 + 5*wch
+}
+
+func wifiOverlap(wch, ch int) bool {
+	d := 2400 + ch - WiFiCenter(wch)
+	return d > -11 && d < 11 // 22 MHz wide channel.
+}
+
+// WiFiChannels returns 2.4 GHz Wi-Fi channels (1..14) that overlap RF channel
+// ch (2400+ch MHz).
+func WiFiChannels(ch int) []int {
+	var wchs []int
+	for wch := 1; wch <= 14; wch++ {
+		if wifiOverlap(wch, ch) {
+			wchs = append(wchs, wch)
+		}
+	}
+	return wchs
+}
+
+// Scanner measures occupancy of RF channels 0..125 using RPD register. It
+// uses Dev in RX mode, so Dev shouldn't be used for communication during
+// scan.
+type Scanner struct {
+	Dev *Device
+
+	// Dwell is time that CE is held high before RPD is sampled. MinDwell is
+	// used if Dwell < MinDwell.
+	Dwell time.Duration
+
+	// Samples is number of RPD samples per channel in one sweep (default 1).
+	Samples int
+
+	// PeakHold selects how Scan combines sweeps: maximum instead of mean.
+	PeakHold bool
+}
+
+// Sweep measures occupancy of all channels once.
+func (sc *Scanner) Sweep(ctx context.Context) (Spectrum, error) {
+	var s Spectrum
+	err := sc.scan(ctx, 1, func(sweep *Spectrum) { s = *sweep })
+	return s, err
+}
+
+// Scan performs n sweeps and returns their mean (or maximum if PeakHold is
+// set). If f is not nil it is called after every sweep (eg. to print ASCII
+// waterfall).
+func (sc *Scanner) Scan(ctx context.Context, n int, f func(sweep Spectrum)) (Spectrum, error) {
+	var s Spectrum
+	err := sc.scan(ctx, n, func(sweep *Spectrum) {
+		for ch, v := range sweep {
+			if !sc.PeakHold {
+				s[ch] += v / float64(n)
+			} else if v > s[ch] {
+				s[ch] = v
+			}
+		}
+		if f != nil {
+			f(*sweep)
+		}
+	})
+	return s, err
+}
+
+// scan sets PRX mode, performs n sweeps and restores CONFIG and RF_CH
+// registers. CE is low after return.
+func (sc *Scanner) scan(ctx context.Context, n int, f func(*Spectrum)) error {
+	d := sc.Dev
+	dwell := sc.Dwell
+	if dwell < MinDwell {
+		dwell = MinDwell
+	}
+	samples := sc.Samples
+	if samples <= 0 {
+		samples = 1
+	}
+	cfg := d.Config()
+	ch := d.Ch()
+	if d.Err != nil {
+		return d.Err
+	}
+	if d.Err = d.SetCE(0); d.Err != nil {
+		return d.Err
+	}
+	d.SetCfg(cfg | PwrUp | PrimRx)
+	if cfg&PwrUp == 0 {
+		time.Sleep(1500 * time.Microsecond) // Tpd2stby
+	}
+	var (
+		s   Spectrum
+		err error
+	)
+loop:
+	for i := 0; i < n; i++ {
+		for c := 0; c < NumCh; c++ {
+			if err = ctx.Err(); err != nil {
+				break loop
+			}
+			d.SetCh(c)
+			hits := 0
+			for k := 0; k < samples && d.Err == nil; k++ {
+				if d.Err = d.SetCE(1); d.Err != nil {
+					break
+				}
+				time.Sleep(dwell)
+				if d.RPD() {
+					hits++
+				}
+				if err := d.SetCE(0); err != nil && d.Err == nil {
+					d.Err = err
+				}
+			}
+			if d.Err != nil {
+				d.SetCE(0) // Best effort: d.Err is returned.
+				return d.Err
+			}
+			s[c] = float64(hits) / float64(samples)
+		}
+		f(&s)
+	}
+	d.SetCh(ch)
+	d.SetCfg(cfg)
+	if d.Err != nil {
+		return d.Err
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package nrf_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+// noisy sets RPD if CE is high and RF_CH is one of busy channels. If fail is
+// not nil it is returned by every read of RPD.
+type noisy struct {
+	*emu.Chip
+	busy map[byte]bool
+	ch   byte
+	fail error
+}
+
+func (d *noisy) WriteRead(oi ...[]byte) (int, error) {
+	n, err := d.Chip.WriteRead(oi...)
+	switch oi[0][0] {
+	case 0x25: // W_REGISTER RF_CH
+		d.ch = oi[2][0]
+	case 0x09: // R_REGISTER RPD
+		if d.fail != nil {
+			return 0, d.fail
+		}
+		if d.CE() && d.busy[d.ch] {
+			oi[3][0] |= 1
+		}
+	}
+	return n, err
+}
+
+func newNoisy(busy ...byte) (*noisy, *nrf.Device) {
+	drv := &noisy{Chip: emu.NewChip(), busy: make(map[byte]bool)}
+	for _, ch := range busy {
+		drv.busy[ch] = true
+	}
+	return drv, &nrf.Device{Driver: drv}
+}
+
+func TestScannerSweep(t *testing.T) {
+	drv, d := newNoisy(10, 40)
+	d.SetCh(33)
+	d.SetCfg(nrf.EnCRC)
+	sc := &nrf.Scanner{Dev: d, Dwell: 10}
+	s, err := sc.Sweep(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ch, v := range s {
+		if want := float64(b2i(drv.busy[byte(ch)])); v != want {
+			t.Errorf("channel %d: %g, want %g", ch, v, want)
+		}
+	}
+	if ch, cfg := d.Ch(), d.Config(); ch != 33 || cfg != nrf.EnCRC {
+		t.Errorf("not restored: RF_CH %d, CONFIG %v", ch, cfg)
+	}
+	if drv.CE() {
+		t.Error("CE left high")
+	}
+	if row := s.String(); len(row) != nrf.NumCh || row[10] != '@' || row[11] != ' ' {
+		t.Errorf("String: %q", row)
+	}
+	if w := s.WiFi(); w[0] == 0 || w[13] != 0 {
+		t.Errorf("WiFi: %v", w)
+	}
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestScannerScan(t *testing.T) {
+	drv, d := newNoisy(5)
+	sc := &nrf.Scanner{Dev: d, Dwell: 10}
+	n := 0
+	f := func(nrf.Spectrum) {
+		n++
+		if n == 2 {
+			drv.busy = map[byte]bool{6: true}
+		}
+	}
+	s, err := sc.Scan(context.Background(), 4, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 || s[5] != 0.5 || s[6] != 0.5 {
+		t.Errorf("mean of %d sweeps: %g %g", n, s[5], s[6])
+	}
+	drv.busy = map[byte]bool{5: true}
+	sc.PeakHold = true
+	s, err = sc.Scan(context.Background(), 2, nil)
+	if err != nil || s[5] != 1 {
+		t.Errorf("peak hold: %g, %v", s[5], err)
+	}
+}
+
+func TestScannerError(t *testing.T) {
+	drv, d := newNoisy()
+	drv.fail = errors.New("SPI failure")
+	sc := &nrf.Scanner{Dev: d, Dwell: 10}
+	if _, err := sc.Sweep(context.Background()); err != drv.fail {
+		t.Errorf("error %v, want %v", err, drv.fail)
+	}
+	if drv.CE() {
+		t.Error("CE left high")
+	}
+
+	_, d = newNoisy()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sc.Dev = d
+	if _, err := sc.Sweep(ctx); err != context.Canceled {
+		t.Errorf("error %v, want context.Canceled", err)
+	}
+	if d.Err != nil {
+		t.Errorf("ctx error is sticky: %v", d.Err)
+	}
+}
+
+func TestWaterfallHeader(t *testing.T) {
+	h := strings.Split(nrf.WaterfallHeader(), "\n")
+	if len(h) != 2 || len(h[0]) != nrf.NumCh || h[0][120] != '2' || h[1][125] != '5' {
+		t.Errorf("header: %q", h)
+	}
+}
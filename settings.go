@@ -0,0 +1,226 @@
+package nrf
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// PipeSettings contains settings of one Rx data pipe.
+type PipeSettings struct {
+	Enabled bool    // EN_RXADDR bit.
+	AA      bool    // EN_AA bit.
+	DPL     bool    // DYNPD bit.
+	PW      int     // Static payload width (RX_PW_Px).
+	Addr    [5]byte // Address, LSByte first. Pipes 2..5 use only Addr[0].
+}
+
+// Settings describes complete configuration of nRF24L01(+) radio. It doesn't
+// contain PrimRx, PwrUp and interrupt mask bits from CONFIG register, which
+// select operational mode rather than radio configuration.
+type Settings struct {
+	DataRate  DataRate
+	Pwr       int  // Output power [dBm]: -18, -12, -6 or 0.
+	LNAHC     bool // nRF24L01 LNA gain (ignored by nRF24L01+).
+	CRC       int  // CRC length in bytes: 0 (disabled), 1 or 2.
+	Ch        int  // RF channel: 0..127.
+	AW        int  // Address width: 3..5.
+	Retr      int  // Number of retransmits: 0..15.
+	RetrDelay int  // Delay between retransmits [µs]: 250..4000.
+	TxAddr    [5]byte
+	Pipes     [6]PipeSettings
+	Feature   Feature
+}
+
+func (s *Settings) check() error {
+	if uint(s.CRC) > 2 {
+		return argErr(ErrCRCLen, s.CRC)
+	}
+	if s.DataRate > DR250k {
+		return argErr(ErrDataRate, int(s.DataRate))
+	}
+	if err := checkALen(s.AW); err != nil {
+		return err
+	}
+	for _, p := range s.Pipes {
+		if err := checkPW(p.PW); err != nil {
+			return err
+		}
+	}
+	if err := checkCh(s.Ch); err != nil {
+		return err
+	}
+	return checkRetr(s.Retr, s.RetrDelay)
+}
+
+func (s *Settings) rf() RF {
+	rf := s.DataRate.RF() | Pwr(s.Pwr)
+	if s.LNAHC {
+		rf |= LNAHC
+	}
+	return rf
+}
+
+func (s *Settings) crcCfg() Config {
+	switch s.CRC {
+	case 1:
+		return EnCRC
+	case 2:
+		return EnCRC | CRCO
+	}
+	return 0
+}
+
+func (s *Settings) pipes(f func(p *PipeSettings) bool) Pipe {
+	var pipes Pipe
+	for pn := range s.Pipes {
+		if f(&s.Pipes[pn]) {
+			pipes |= 1 << uint(pn)
+		}
+	}
+	return pipes
+}
+
+// Apply writes s to registers of d and next reads them back to verify that d
+// holds exactly s. Apply should be called in Power Down or Standby-I mode (CE
+// low). CONFIG register is written last, so its PrimRx, PwrUp and mask bits
+// are preserved. Registers are read back from chip even if d.Cache is used.
+func (s *Settings) Apply(d *Device) error {
+	if !d.check(s.check()) {
+		return d.Err
+	}
+	cfg := d.Config()&^(EnCRC|CRCO) | s.crcCfg()
+	d.SetFeature(s.Feature)
+	d.SetRF(s.rf())
+	d.SetCh(s.Ch)
+	d.SetALen(s.AW)
+	d.SetRetr(s.Retr, s.RetrDelay)
+	d.SetTxAddr(s.TxAddr[:s.AW]...)
+	for pn := range s.Pipes {
+		p := &s.Pipes[pn]
+		if pn < 2 {
+			d.SetRxAddr(pn, p.Addr[:s.AW]...)
+		} else {
+			d.SetRxAddr(pn, p.Addr[0])
+		}
+		d.SetRxPW(pn, p.PW)
+	}
+	d.SetRxAE(s.pipes(func(p *PipeSettings) bool { return p.Enabled }))
+	d.SetDynPD(s.pipes(func(p *PipeSettings) bool { return p.DPL }))
+	d.SetAA(s.pipes(func(p *PipeSettings) bool { return p.AA }))
+	d.SetCfg(cfg)
+	if d.Err != nil {
+		return d.Err
+	}
+	var (
+		have Settings
+		err  error
+	)
+	d.readThrough(func() { have, err = ReadSettings(d) })
+	if err != nil {
+		return err
+	}
+	want := s.norm()
+	want.LNAHC = have.LNAHC // Bit 0 of RF_SETUP is obsolete in nRF24L01+.
+	if diff := want.diff(&have); len(diff) != 0 {
+		return &VerifyError{Fields: diff}
+	}
+	return nil
+}
+
+// norm returns s in form returned by ReadSettings.
+func (s *Settings) norm() Settings {
+	n := *s
+	n.Pwr = Pwr(s.Pwr).Pwr()
+	n.RetrDelay = s.RetrDelay / 250 * 250
+	n.Feature &= DPL | AckPay | DynAck
+	for i := s.AW; i < len(n.TxAddr); i++ {
+		n.TxAddr[i] = 0
+		n.Pipes[0].Addr[i] = 0
+		n.Pipes[1].Addr[i] = 0
+	}
+	for pn := 2; pn < len(n.Pipes); pn++ {
+		a := &n.Pipes[pn].Addr
+		*a = [5]byte{a[0]}
+	}
+	return n
+}
+
+// diff returns names of fields that differ in s and o.
+func (s *Settings) diff(o *Settings) []string {
+	var names []string
+	sv := reflect.ValueOf(s).Elem()
+	ov := reflect.ValueOf(o).Elem()
+	for i := 0; i < sv.NumField(); i++ {
+		name := sv.Type().Field(i).Name
+		if name != "Pipes" {
+			if sv.Field(i).Interface() != ov.Field(i).Interface() {
+				names = append(names, name)
+			}
+			continue
+		}
+		for pn := range s.Pipes {
+			pv := reflect.ValueOf(s.Pipes[pn])
+			qv := reflect.ValueOf(o.Pipes[pn])
+			for k := 0; k < pv.NumField(); k++ {
+				if pv.Field(k).Interface() != qv.Field(k).Interface() {
+					names = append(names, fmt.Sprintf(
+						"Pipes[%d].%s", pn, pv.Type().Field(k).Name,
+					))
+				}
+			}
+		}
+	}
+	return names
+}
+
+// ReadSettings reads current settings from d.
+func ReadSettings(d *Device) (Settings, error) {
+	var s Settings
+	rf := d.RF()
+	s.DataRate = rf.DataRate()
+	s.Pwr = rf.Pwr()
+	s.LNAHC = rf&LNAHC != 0
+	cfg := d.Config()
+	switch {
+	case cfg&EnCRC == 0:
+		s.CRC = 0
+	case cfg&CRCO == 0:
+		s.CRC = 1
+	default:
+		s.CRC = 2
+	}
+	s.Ch = d.Ch()
+	s.AW = d.AW()
+	if s.AW > 5 {
+		s.AW = 5 // SETUP_AW contains illegal value.
+	}
+	s.Retr, s.RetrDelay = d.Retr()
+	d.TxAddr(s.TxAddr[:s.AW])
+	aa, rxae, dynpd := d.AA(), d.RxAE(), d.DynPD()
+	for pn := range s.Pipes {
+		p := &s.Pipes[pn]
+		bit := Pipe(1) << uint(pn)
+		p.Enabled = rxae&bit != 0
+		p.AA = aa&bit != 0
+		p.DPL = dynpd&bit != 0
+		p.PW = d.RxPW(pn)
+		if pn < 2 {
+			d.RxAddr(pn, p.Addr[:s.AW])
+		} else {
+			p.Addr[0] = d.RxAddr0(pn)
+		}
+	}
+	s.Feature = d.Feature()
+	return s, d.Err
+}
+
+// VerifyError is returned by Settings.Apply if settings read back from device
+// differ from applied ones.
+type VerifyError struct {
+	Fields []string // Names of fields that differ.
+}
+
+func (e *VerifyError) Error() string {
+	return "nrf: settings not applied: " + strings.Join(e.Fields, ", ")
+}
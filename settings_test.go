@@ -0,0 +1,106 @@
+package nrf_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+func testSettings() nrf.Settings {
+	s := nrf.Settings{
+		DataRate:  nrf.DR250k,
+		Pwr:       -6,
+		CRC:       1,
+		Ch:        100,
+		AW:        4,
+		Retr:      7,
+		RetrDelay: 1000,
+		TxAddr:    [5]byte{1, 2, 3, 4},
+		Feature:   nrf.DPL | nrf.AckPay,
+	}
+	s.Pipes[0] = nrf.PipeSettings{Enabled: true, AA: true, DPL: true, Addr: s.TxAddr}
+	s.Pipes[1] = nrf.PipeSettings{Enabled: true, PW: 12, Addr: [5]byte{5, 6, 7, 8}}
+	s.Pipes[3] = nrf.PipeSettings{Enabled: true, AA: true, PW: 32, Addr: [5]byte{9}}
+	return s
+}
+
+func TestSettingsApply(t *testing.T) {
+	d := &nrf.Device{Driver: emu.NewChip()}
+	d.SetCfg(nrf.PwrUp | nrf.PrimRx)
+	s := testSettings()
+	if err := s.Apply(d); err != nil {
+		t.Fatal(err)
+	}
+	have, err := nrf.ReadSettings(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have != s {
+		t.Errorf("ReadSettings:\n%+v\nwant:\n%+v", have, s)
+	}
+	if cfg := d.Config(); cfg != nrf.PwrUp|nrf.PrimRx|nrf.EnCRC {
+		t.Errorf("CONFIG = %v: mode bits not preserved", cfg)
+	}
+}
+
+func TestSettingsApplyNormalized(t *testing.T) {
+	d := &nrf.Device{Driver: emu.NewChip()}
+	s := testSettings()
+	s.RetrDelay = 1100 // Rounded down to 1000 µs.
+	s.Pipes[2].Addr = [5]byte{7, 7, 7}
+	if err := s.Apply(d); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSettingsApplyLNAHC(t *testing.T) {
+	s := testSettings()
+	s.LNAHC = true
+	for _, drv := range []nrf.Driver{emu.NewChip(), si24r1()} {
+		d := &nrf.Device{Driver: drv}
+		if err := s.Apply(d); err != nil {
+			t.Errorf("%T: %v", drv, err)
+		}
+	}
+}
+
+func TestSettingsApplyInvalid(t *testing.T) {
+	sp := &spy{Chip: emu.NewChip()}
+	d := &nrf.Device{Driver: sp}
+	s := testSettings()
+	s.CRC = 3
+	err := s.Apply(d)
+	if !errors.Is(err, nrf.ErrCRCLen) {
+		t.Fatalf("error %v, want ErrCRCLen", err)
+	}
+	if len(sp.cmds) != 0 {
+		t.Errorf("invalid settings written: %x", sp.cmds)
+	}
+}
+
+// stuckCh ignores writes to RF_CH register.
+type stuckCh struct {
+	*emu.Chip
+}
+
+func (c stuckCh) WriteRead(oi ...[]byte) (int, error) {
+	if len(oi) > 0 && len(oi[0]) > 0 && oi[0][0] == 0x25 {
+		return 0, nil
+	}
+	return c.Chip.WriteRead(oi...)
+}
+
+func TestSettingsVerifyError(t *testing.T) {
+	d := &nrf.Device{Driver: stuckCh{emu.NewChip()}}
+	s := testSettings()
+	err := s.Apply(d)
+	var ve *nrf.VerifyError
+	if !errors.As(err, &ve) {
+		t.Fatalf("error %v, want VerifyError", err)
+	}
+	if len(ve.Fields) != 1 || ve.Fields[0] != "Ch" {
+		t.Errorf("VerifyError.Fields = %q, want [Ch]", ve.Fields)
+	}
+}
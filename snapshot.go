@@ -0,0 +1,190 @@
+package nrf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+func fmtByte(b []byte) string   { return "0x" + hex.EncodeToString(b[:1]) }
+func fmtAddr(b []byte) string   { return hex.EncodeToString(b) }
+func fmtConfig(b []byte) string { return Config(b[0]).String() }
+func fmtPipe(b []byte) string   { return Pipe(b[0]).String() }
+func fmtRF(b []byte) string     { return RF(b[0]).String() }
+func fmtStatus(b []byte) string { return Status(b[0]).String() }
+func fmtFIFO(b []byte) string   { return FIFO(b[0]).String() }
+func fmtFeat(b []byte) string   { return Feature(b[0]).String() }
+
+func fmtAW(b []byte) string {
+	return strconv.Itoa(int(b[0]&3) + 2)
+}
+
+func fmtRetr(b []byte) string {
+	cnt, dlyus := retr(b[0])
+	return fmt.Sprintf("%d times, %d us", cnt, dlyus)
+}
+
+func fmtTxCnt(b []byte) string {
+	return fmt.Sprintf("%d pkt lost, %d retr", b[0]>>4, b[0]&0xf)
+}
+
+func fmtDec(b []byte) string {
+	return strconv.Itoa(int(b[0]))
+}
+
+// regs describes registers 0x00-0x1d.
+var regs = [0x1e]struct {
+	name string
+	fmt  func([]byte) string
+}{
+	{"CONFIG", fmtConfig},
+	{"EN_AA", fmtPipe},
+	{"EN_RXADDR", fmtPipe},
+	{"SETUP_AW", fmtAW},
+	{"SETUP_RETR", fmtRetr},
+	{"RF_CH", fmtDec},
+	{"RF_SETUP", fmtRF},
+	{"STATUS", fmtStatus},
+	{"OBSERVE_TX", fmtTxCnt},
+	{"RPD", fmtByte},
+	{"RX_ADDR_P0", fmtAddr},
+	{"RX_ADDR_P1", fmtAddr},
+	{"RX_ADDR_P2", fmtByte},
+	{"RX_ADDR_P3", fmtByte},
+	{"RX_ADDR_P4", fmtByte},
+	{"RX_ADDR_P5", fmtByte},
+	{"TX_ADDR", fmtAddr},
+	{"RX_PW_P0", fmtDec},
+	{"RX_PW_P1", fmtDec},
+	{"RX_PW_P2", fmtDec},
+	{"RX_PW_P3", fmtDec},
+	{"RX_PW_P4", fmtDec},
+	{"RX_PW_P5", fmtDec},
+	{"FIFO_STATUS", fmtFIFO},
+	{"RESERVED_18", fmtByte},
+	{"RESERVED_19", fmtByte},
+	{"RESERVED_1A", fmtByte},
+	{"RESERVED_1B", fmtByte},
+	{"DYNPD", fmtPipe},
+	{"FEATURE", fmtFeat},
+}
+
+// Snapshot contains values of all nRF24L01(+) registers.
+type Snapshot struct {
+	// Reg contains values of one-byte registers. Reg[0x0a], Reg[0x0b] and
+	// Reg[0x10] are unused: see RxAddr0, RxAddr1, TxAddr.
+	Reg [0x1e]byte
+
+	RxAddr0, RxAddr1, TxAddr [5]byte // Full RX_ADDR_P0, RX_ADDR_P1, TX_ADDR.
+}
+
+// val returns value of register at addr.
+func (s *Snapshot) val(addr byte) []byte {
+	switch addr {
+	case 0x0a:
+		return s.RxAddr0[:]
+	case 0x0b:
+		return s.RxAddr1[:]
+	case 0x10:
+		return s.TxAddr[:]
+	}
+	return s.Reg[addr : addr+1]
+}
+
+// Snapshot reads all registers of d from chip (d.Cache isn't used).
+func (d *Device) Snapshot() *Snapshot {
+	s := new(Snapshot)
+	d.readThrough(func() {
+		for addr := range regs {
+			d.Reg(byte(addr), s.val(byte(addr)))
+		}
+	})
+	return s
+}
+
+// Restore writes all writable registers of d using values from s. STATUS
+// register isn't written so interrupt flags aren't cleared.
+func (s *Snapshot) Restore(d *Device) {
+	d.SetReg(0x1d, s.Reg[0x1d]) // FEATURE before DYNPD.
+	d.SetReg(0x1c, s.Reg[0x1c])
+	for addr := byte(1); addr <= 0x16; addr++ {
+		switch addr {
+		case 0x07, 0x08, 0x09: // STATUS and read-only registers.
+			continue
+		}
+		d.SetReg(addr, s.val(addr)...)
+	}
+	d.SetReg(0, s.Reg[0]) // CONFIG at the end.
+}
+
+func (s *Snapshot) String() string {
+	var buf bytes.Buffer
+	for addr, r := range regs {
+		fmt.Fprintf(&buf, " %-13s%s\n", r.name+":", r.fmt(s.val(byte(addr))))
+	}
+	return buf.String()
+}
+
+// Change describes register that differs in two snapshots.
+type Change struct {
+	Reg      string // Name of register.
+	Old, New string // Formated values.
+}
+
+func (c Change) String() string {
+	return c.Reg + ": " + c.Old + " -> " + c.New
+}
+
+// Diff returns list of registers that differ in a and b.
+func Diff(a, b *Snapshot) []Change {
+	var changes []Change
+	for addr, r := range regs {
+		av, bv := a.val(byte(addr)), b.val(byte(addr))
+		if !bytes.Equal(av, bv) {
+			changes = append(changes, Change{r.name, r.fmt(av), r.fmt(bv)})
+		}
+	}
+	return changes
+}
+
+// MarshalJSON encodes s as JSON object that maps register names to their
+// values encoded in hex.
+func (s *Snapshot) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for addr, r := range regs {
+		if addr != 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%q:%q", r.name, hex.EncodeToString(s.val(byte(addr))))
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes s from JSON encoded by MarshalJSON. Missing registers
+// are left unchanged.
+func (s *Snapshot) UnmarshalJSON(data []byte) error {
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	for addr, r := range regs {
+		v, ok := m[r.name]
+		if !ok {
+			continue
+		}
+		b, err := hex.DecodeString(v)
+		if err != nil {
+			return fmt.Errorf("nrf: snapshot %s: %v", r.name, err)
+		}
+		dst := s.val(byte(addr))
+		if len(b) != len(dst) {
+			return fmt.Errorf("nrf: snapshot %s: bad length %d", r.name, len(b))
+		}
+		copy(dst, b)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package nrf_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+func TestSnapshotJSON(t *testing.T) {
+	d := &nrf.Device{Driver: emu.NewChip()}
+	d.SetCh(33)
+	d.SetTxAddr(1, 2, 3, 4, 5)
+	s := d.Snapshot()
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["RF_CH"] != "21" || m["TX_ADDR"] != "0102030405" {
+		t.Errorf("RF_CH: %q, TX_ADDR: %q", m["RF_CH"], m["TX_ADDR"])
+	}
+	var s1 nrf.Snapshot
+	if err := json.Unmarshal(data, &s1); err != nil {
+		t.Fatal(err)
+	}
+	if s1 != *s {
+		t.Errorf("round trip:\n%s\nwant:\n%s", &s1, s)
+	}
+	if err := json.Unmarshal([]byte(`{"RF_CH":"0102"}`), &s1); err == nil {
+		t.Error("no error for RF_CH of length 2")
+	}
+}
+
+func TestSnapshotDiffRestore(t *testing.T) {
+	d := &nrf.Device{Driver: emu.NewChip()}
+	orig := d.Snapshot()
+	d.SetCh(10)
+	d.SetRxAddr(1, 9, 9, 9, 9, 9)
+	d.SetCfg(nrf.PwrUp)
+	changed := d.Snapshot()
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	diff := nrf.Diff(orig, changed)
+	want := []string{"CONFIG", "RF_CH", "RX_ADDR_P1"}
+	if len(diff) != len(want) {
+		t.Fatalf("Diff: %v, want changes of %v", diff, want)
+	}
+	for i, c := range diff {
+		if c.Reg != want[i] {
+			t.Errorf("Diff[%d]: %v, want change of %s", i, c, want[i])
+		}
+	}
+	if c := diff[1]; c.Old != "2" || c.New != "10" {
+		t.Errorf("RF_CH change: %v", c)
+	}
+	orig.Restore(d)
+	if diff := nrf.Diff(orig, d.Snapshot()); len(diff) != 0 || d.Err != nil {
+		t.Errorf("after Restore: %v %v", diff, d.Err)
+	}
+}
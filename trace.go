@@ -0,0 +1,110 @@
+package nrf
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// Tracer describes how Driver returned by Wrap reports SPI transactions and
+// changes of CE line. Every transaction is decoded by Decode.
+type Tracer struct {
+	W    io.Writer    // If not nil, one line per transaction is written to W.
+	Log  *slog.Logger // If not nil, transactions are logged at debug level.
+	Time bool         // Prefix lines written to W with time.
+	Dur  bool         // Append duration of driver call.
+}
+
+// Wrap returns Driver that calls drv and traces its calls. Returned Driver
+// implements IRQWaiter if drv implements it. It always implements Batcher.
+func (t *Tracer) Wrap(drv Driver) Driver {
+	td := &traceDriver{Driver: drv, t: t}
+	if w, ok := drv.(IRQWaiter); ok {
+		return &traceIRQDriver{td, w}
+	}
+	return td
+}
+
+func (t *Tracer) trace(start time.Time, dur time.Duration, msg string, err error) {
+	if err != nil {
+		msg += " error: " + err.Error()
+	}
+	if t.W != nil {
+		line := msg
+		if t.Time {
+			line = start.Format("15:04:05.000000 ") + line
+		}
+		if t.Dur {
+			line += " " + dur.String()
+		}
+		fmt.Fprintln(t.W, line)
+	}
+	if t.Log != nil {
+		t.Log.Debug(msg, "dur", dur)
+	}
+}
+
+type traceDriver struct {
+	Driver
+	t *Tracer
+}
+
+func (d *traceDriver) WriteRead(oi ...[]byte) (n int, err error) {
+	start := time.Now()
+	n, err = d.Driver.WriteRead(oi...)
+	d.t.trace(start, time.Since(start), Decode(Flatten(oi)), err)
+	return n, err
+}
+
+func (d *traceDriver) WriteReadBatch(txs ...[][]byte) error {
+	b, ok := d.Driver.(Batcher)
+	if !ok {
+		for _, oi := range txs {
+			if _, err := d.WriteRead(oi...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	start := time.Now()
+	err := b.WriteReadBatch(txs...)
+	dur := time.Since(start)
+	for i, oi := range txs {
+		d.t.trace(start, dur, fmt.Sprintf("batch %d/%d: %s", i+1, len(txs), Decode(Flatten(oi))), err)
+	}
+	return err
+}
+
+var ceNames = [...]string{"low", "high", "pulse"}
+
+func (d *traceDriver) SetCE(v int) error {
+	start := time.Now()
+	err := d.Driver.SetCE(v)
+	s := fmt.Sprint(v)
+	if uint(v) < uint(len(ceNames)) {
+		s = ceNames[v]
+	}
+	d.t.trace(start, time.Since(start), "CE <- "+s, err)
+	return err
+}
+
+type traceIRQDriver struct {
+	*traceDriver
+	w IRQWaiter
+}
+
+func (d *traceIRQDriver) WaitIRQ(ctx context.Context) error {
+	start := time.Now()
+	err := d.w.WaitIRQ(ctx)
+	d.t.trace(start, time.Since(start), "WaitIRQ", err)
+	return err
+}
+
+func (d *traceIRQDriver) IRQ() (bool, error) {
+	start := time.Now()
+	irq, err := d.w.IRQ()
+	d.t.trace(start, time.Since(start), fmt.Sprint("IRQ -> ", irq), err)
+	return irq, err
+}
@@ -0,0 +1,98 @@
+package nrf_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+func TestTracer(t *testing.T) {
+	var buf bytes.Buffer
+	tr := &nrf.Tracer{W: &buf}
+	d := &nrf.Device{Driver: tr.Wrap(emu.NewChip())}
+	d.SetCfg(nrf.PwrUp)
+	d.SetCh(5)
+	d.Ch()
+	d.WriteTxP([]byte{1, 2})
+	d.SetCE(2)
+	w, ok := d.Driver.(nrf.IRQWaiter)
+	if !ok {
+		t.Fatal("IRQWaiter not implemented")
+	}
+	if err := w.WaitIRQ(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var b nrf.Batch
+	b.Reg(5, 1)
+	b.FlushTx()
+	d.Exec(&b)
+	d.SetCE(7)
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	want := []string{
+		"W_REGISTER CONFIG <- ",
+		"W_REGISTER RF_CH <- 5 (STATUS ",
+		"R_REGISTER RF_CH -> 5 (STATUS ",
+		"W_TX_PAYLOAD <- 0102 (STATUS ",
+		"CE <- pulse",
+		"WaitIRQ",
+		"batch 1/2: R_REGISTER RF_CH -> 5 (STATUS ",
+		"batch 2/2: FLUSH_TX (STATUS ",
+		"CE <- 7",
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("trace:\n%s", buf.String())
+	}
+	for i, l := range lines {
+		if !strings.HasPrefix(l, want[i]) {
+			t.Errorf("line %d: %q, want prefix %q", i, l, want[i])
+		}
+	}
+}
+
+func TestTracerNoIRQ(t *testing.T) {
+	var buf bytes.Buffer
+	tr := &nrf.Tracer{
+		Log: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+	drv := tr.Wrap(noIRQ{emu.NewChip()})
+	if _, ok := drv.(nrf.IRQWaiter); ok {
+		t.Error("IRQWaiter implemented for driver without it")
+	}
+	d := &nrf.Device{Driver: drv}
+	var b nrf.Batch
+	b.NOP()
+	b.NOP()
+	d.Exec(&b) // Batcher emulated by WriteRead calls.
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	if n := strings.Count(buf.String(), `msg="NOP (STATUS `); n != 2 {
+		t.Errorf("log:\n%s", buf.String())
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		mosi, miso []byte
+		want       string
+	}{
+		{nil, nil, "empty transaction"},
+		{[]byte{0xa9, 7}, nil, "W_ACK_PAYLOAD P1 <- 07"},
+		{[]byte{0x05, 0}, []byte{0x0e, 42}, "R_REGISTER RF_CH -> 42"},
+		{[]byte{0x3f}, nil, "W_REGISTER REG_1F <- "},
+		{[]byte{0x77}, nil, "CMD_77"},
+	}
+	for _, tc := range tests {
+		if s := nrf.Decode(tc.mosi, tc.miso); !strings.HasPrefix(s, tc.want) {
+			t.Errorf("Decode(%x, %x) = %q, want prefix %q", tc.mosi, tc.miso, s, tc.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package nrf
+
+import (
+	"context"
+	"time"
+)
+
+// TxResult describes result of Transmitter.Send.
+type TxResult struct {
+	ARC    int    // Number of retransmits (from OBSERVE_TX).
+	AckPay []byte // Payload received with ACK or nil.
+}
+
+// Transmitter sends payloads using Dev configured as powered up PTX. It
+// controls CE line itself so CE should be low when Send is called.
+type Transmitter struct {
+	Dev   *Device
+	NoAck bool // Use W_TX_PAYLOAD_NOACK command (requires DynAck feature).
+
+	// Poll is STATUS polling interval used if Dev.Driver doesn't implement
+	// IRQWaiter (see Device.WaitIRQ).
+	Poll time.Duration
+
+	// Stats, if not nil, is updated after every transmission.
+	Stats *LinkStats
+}
+
+// Send writes pay to Tx FIFO, sets CE high and waits until pay is sent or
+// maximum number of retransmits is reached (ErrMaxRetransmits is returned).
+// Payloads that were in Tx FIFO before are sent first. If Tx FIFO is full
+// ErrTxFull is returned. TxDS and MaxRT flags left by previous transmissions
+// are cleared. Send always leaves Tx FIFO empty and CE low. It returns
+// ctx.Err() if ctx is done before transmission ends. Errors returned by Dev
+// are sticky (see Device.Err).
+func (t *Transmitter) Send(ctx context.Context, pay []byte) (TxResult, error) {
+	var res TxResult
+	d := t.Dev
+	d.NOP()
+	if d.Err != nil {
+		return res, d.Err
+	}
+	if d.Status&FullTx != 0 {
+		return res, ErrTxFull
+	}
+	if d.Status&(TxDS|MaxRT) != 0 {
+		d.Clear(TxDS | MaxRT)
+	}
+	if t.NoAck {
+		d.WriteTxPNoAck(pay)
+	} else {
+		d.WriteTxP(pay)
+	}
+	if d.Err != nil {
+		return res, d.Err
+	}
+	if d.Err = d.SetCE(1); d.Err != nil {
+		return res, d.Err
+	}
+	for {
+		if err := d.WaitIRQ(ctx, t.Poll); err != nil {
+			if d.Err != nil {
+				return res, d.Err
+			}
+			return res, t.end(err)
+		}
+		if d.Status&RxDR != 0 {
+			res.AckPay = t.readAckPay(res.AckPay)
+		}
+		if d.Status&MaxRT != 0 {
+			// MaxRT must be cleared with CE low, otherwise the chip
+			// retransmits the payload again.
+			_, res.ARC = d.TxCnt()
+			return res, t.done(ErrMaxRetransmits)
+		}
+		if d.Status&TxDS != 0 {
+			d.Clear(TxDS)
+			if d.FIFO()&TxEmpty != 0 {
+				_, res.ARC = d.TxCnt()
+				return res, t.done(nil)
+			}
+		}
+	}
+}
+
+// readAckPay reads all ACK payloads from Rx FIFO and returns the last one.
+func (t *Transmitter) readAckPay(last []byte) []byte {
+	d := t.Dev
+	for {
+		plen := d.RxPLen()
+		if d.Err != nil {
+			return last
+		}
+		if plen > 32 {
+			d.FlushRx()
+		} else {
+			last = make([]byte, plen)
+			d.ReadRxP(last)
+		}
+		d.Clear(RxDR)
+		if d.FIFO()&RxEmpty != 0 {
+			return last
+		}
+	}
+}
+
+// done ends transmission and updates t.Stats.
+func (t *Transmitter) done(err error) error {
+	err = t.end(err)
+	if t.Stats != nil && (err == nil || err == ErrMaxRetransmits) {
+		t.Stats.Update(t.Dev, err == nil)
+		if t.Dev.Err != nil {
+			return t.Dev.Err
+		}
+	}
+	return err
+}
+
+// end sets CE low, flushes Tx FIFO and clears MaxRT flag. It returns Dev.Err
+// if not nil or err.
+func (t *Transmitter) end(err error) error {
+	d := t.Dev
+	if d.Err == nil {
+		d.Err = d.SetCE(0)
+	}
+	d.FlushTx()
+	d.Clear(MaxRT)
+	if d.Err != nil {
+		return d.Err
+	}
+	return err
+}
@@ -0,0 +1,127 @@
+package nrf_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ziutek/nrf"
+	"github.com/ziutek/nrf/emu"
+)
+
+// link returns powered up PTX (a) and PRX (b) devices that use emulated chips
+// linked by one Ether. CE of both chips is low.
+func link(t *testing.T, retr int) (a, b *nrf.Device) {
+	t.Helper()
+	e := emu.NewEther()
+	s := nrf.Settings{
+		DataRate:  nrf.DR1M,
+		CRC:       2,
+		Ch:        76,
+		AW:        5,
+		Retr:      retr,
+		RetrDelay: 500,
+		TxAddr:    [5]byte{1, 2, 3, 4, 5},
+		Feature:   nrf.DPL | nrf.AckPay,
+	}
+	s.Pipes[0] = nrf.PipeSettings{Enabled: true, AA: true, DPL: true, Addr: s.TxAddr}
+	s.Pipes[1] = nrf.PipeSettings{Enabled: true, AA: true, DPL: true, Addr: [5]byte{6, 6, 6, 6, 6}}
+	a = &nrf.Device{Driver: e.NewChip()}
+	b = &nrf.Device{Driver: e.NewChip()}
+	for _, d := range []*nrf.Device{a, b} {
+		if err := s.Apply(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.SetCfg(nrf.EnCRC | nrf.CRCO | nrf.PwrUp)
+	b.SetCfg(nrf.EnCRC | nrf.CRCO | nrf.PwrUp | nrf.PrimRx)
+	if a.Err != nil || b.Err != nil {
+		t.Fatal(a.Err, b.Err)
+	}
+	return a, b
+}
+
+// checkIdle checks that d has CE low, empty Tx FIFO and no TxDS and MaxRT
+// flags set.
+func checkIdle(t *testing.T, d *nrf.Device) {
+	t.Helper()
+	if d.Driver.(*emu.Chip).CE() {
+		t.Error("CE left high")
+	}
+	fifo := d.FIFO()
+	d.NOP()
+	if d.Err != nil {
+		t.Fatal(d.Err)
+	}
+	if fifo&nrf.TxEmpty == 0 {
+		t.Errorf("Tx FIFO not empty: %v", fifo)
+	}
+	if d.Status&(nrf.TxDS|nrf.MaxRT) != 0 {
+		t.Errorf("flags left set: %v", d.Status)
+	}
+}
+
+func TestTransmitterSend(t *testing.T) {
+	a, b := link(t, 3)
+	if err := b.SetCE(1); err != nil {
+		t.Fatal(err)
+	}
+	b.WriteAckP(0, []byte("ack"))
+	tx := &nrf.Transmitter{Dev: a}
+	res, err := tx.Send(context.Background(), []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ARC != 0 || string(res.AckPay) != "ack" {
+		t.Errorf("result: %+v", res)
+	}
+	checkIdle(t, a)
+	pay := make([]byte, b.RxPLen())
+	b.ReadRxP(pay)
+	if b.Err != nil || string(pay) != "hello" {
+		t.Errorf("PRX received %q, %v", pay, b.Err)
+	}
+}
+
+func TestTransmitterMaxRT(t *testing.T) {
+	a, _ := link(t, 4) // PRX with CE low doesn't receive.
+	tx := &nrf.Transmitter{Dev: a}
+	for i := 0; i < 2; i++ {
+		res, err := tx.Send(context.Background(), []byte{1})
+		if err != nrf.ErrMaxRetransmits {
+			t.Fatalf("error %v, want ErrMaxRetransmits", err)
+		}
+		if res.ARC != 4 {
+			t.Errorf("ARC = %d, want 4", res.ARC)
+		}
+		checkIdle(t, a)
+	}
+}
+
+func TestTransmitterTxFull(t *testing.T) {
+	a, _ := link(t, 3)
+	for i := 0; i < 3; i++ {
+		a.WriteTxP([]byte{byte(i)})
+	}
+	tx := &nrf.Transmitter{Dev: a}
+	if _, err := tx.Send(context.Background(), []byte{3}); err != nrf.ErrTxFull {
+		t.Fatalf("error %v, want ErrTxFull", err)
+	}
+}
+
+func TestTransmitterCancel(t *testing.T) {
+	a, _ := link(t, 3)
+	a.SetCfg(nrf.EnCRC | nrf.CRCO) // Powered down chip never transmits.
+	tx := &nrf.Transmitter{Dev: a}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := tx.Send(ctx, []byte{1})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v, want DeadlineExceeded", err)
+	}
+	if a.Err != nil {
+		t.Fatalf("ctx error is sticky: %v", a.Err)
+	}
+	checkIdle(t, a)
+}
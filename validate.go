@@ -0,0 +1,201 @@
+package nrf
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// MinARD returns minimal ARD (auto retransmit delay) [µs] that allows PTX to
+// receive ACK packet that carries plen bytes of payload. aw is address width,
+// crc is CRC length in bytes. ARD must cover Rx settling time (130 µs) and air
+// time of ACK packet.
+func MinARD(dr DataRate, aw, crc, plen int) int {
+	const settling = 130
+	var t int
+	switch dr {
+	case DR250k:
+		t = settling + (8*(1+aw+plen+crc)+9)*4
+	case DR2M:
+		t = settling + (8*(2+aw+plen+crc)+9)/2
+	default:
+		t = settling + 8*(1+aw+plen+crc) + 9
+	}
+	return (t + 249) / 250 * 250
+}
+
+// Severity describes how serious is a problem described by Finding.
+type Severity byte
+
+const (
+	Info    Severity = iota // Setting is legal but probably unintended.
+	Warning                 // Setting can cause unreliable communication.
+	Invalid                 // Setting doesn't work or is ignored by the chip.
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Invalid:
+		return "invalid"
+	}
+	return "Severity(" + strconv.Itoa(int(s)) + ")"
+}
+
+// Finding describes inconsistency found by Validate.
+type Finding struct {
+	Severity Severity
+	Reg      string // Names of registers involved.
+	Msg      string // Explanation.
+}
+
+func (f Finding) String() string {
+	return f.Severity.String() + ": " + f.Reg + ": " + f.Msg
+}
+
+// Worst returns the highest severity in fs (Info if fs is empty).
+func Worst(fs []Finding) Severity {
+	w := Info
+	for _, f := range fs {
+		if f.Severity > w {
+			w = f.Severity
+		}
+	}
+	return w
+}
+
+// Validate checks register values in s for inconsistent or invalid settings.
+// It returns nil if no problem was found. PTX specific checks (ARD, ACK
+// address) are performed only if PRIM_RX bit in s.Reg[0] (CONFIG) is 0.
+func Validate(s *Snapshot) []Finding {
+	var fs []Finding
+	add := func(sev Severity, reg, format string, a ...interface{}) {
+		fs = append(fs, Finding{sev, reg, fmt.Sprintf(format, a...)})
+	}
+	cfg := Config(s.Reg[0])
+	aa := Pipe(s.Reg[1]) & PAll
+	rxae := Pipe(s.Reg[2]) & PAll
+	dynpd := Pipe(s.Reg[0x1c]) & PAll
+	feature := Feature(s.Reg[0x1d])
+	rf := RF(s.Reg[6])
+	cnt, dlyus := retr(s.Reg[4])
+	ptx := cfg&PrimRx == 0
+
+	aw := int(s.Reg[3]&3) + 2
+	if aw == 2 {
+		add(Invalid, "SETUP_AW", "value 0 is illegal")
+		aw = 3
+	}
+	crc := 0
+	if cfg&EnCRC != 0 || aa != 0 {
+		crc = 1
+		if cfg&CRCO != 0 {
+			crc = 2
+		}
+	}
+	if aa != 0 && cfg&EnCRC == 0 {
+		add(Warning, "CONFIG, EN_AA",
+			"EN_CRC is 0 but chip forces it to 1 because EN_AA=%s", aa)
+	}
+	if dynpd != 0 && feature&DPL == 0 {
+		add(Invalid, "DYNPD, FEATURE",
+			"DYNPD=%s has no effect without FEATURE.DPL", dynpd)
+	}
+	if dynpd&^aa != 0 {
+		add(Invalid, "DYNPD, EN_AA",
+			"dynamic payload length requires auto ack but EN_AA=%s", aa)
+	}
+	if feature&AckPay != 0 {
+		if feature&DPL == 0 {
+			add(Invalid, "FEATURE", "AckPay requires DPL")
+		}
+		if dynpd&(P0|P1) != P0|P1 {
+			add(Invalid, "FEATURE, DYNPD",
+				"AckPay requires dynamic payload length on pipe 0 and 1")
+		}
+	}
+	if ptx && aa != 0 {
+		plen := 0
+		if feature&AckPay != 0 {
+			plen = 32
+		}
+		if min := MinARD(rf.DataRate(), aw, crc, plen); dlyus < min {
+			sev := Warning
+			if MinARD(rf.DataRate(), aw, crc, 0) > dlyus {
+				sev = Invalid // Even empty ACK can't be received.
+			}
+			add(sev, "SETUP_RETR, RF_SETUP",
+				"ARD=%d us is too short for %d byte ACK payload at %s (%d us needed)",
+				dlyus, plen, rf.DataRate(), min)
+		}
+		if cnt == 0 {
+			add(Info, "SETUP_RETR", "auto retransmission disabled")
+		}
+	}
+	if rf&DRLow != 0 && rf&DRHigh != 0 {
+		add(Warning, "RF_SETUP", "RF_DR_HIGH is ignored if RF_DR_LOW is set")
+	}
+	if rf&(Wave|Lock) != 0 {
+		add(Warning, "RF_SETUP", "test mode bits set: %s", rf)
+	}
+	if ch := int(s.Reg[5]); ch > 83 {
+		add(Warning, "RF_CH",
+			"channel %d (%d MHz) is above 2483.5 MHz band edge", ch, 2400+ch)
+	}
+	for pn := 0; pn < 6; pn++ {
+		bit := Pipe(1) << uint(pn)
+		pw := int(s.Reg[0x11+pn])
+		reg := fmt.Sprintf("RX_PW_P%d", pn)
+		if pw > 32 {
+			add(Invalid, reg, "payload width %d > 32", pw)
+		}
+		if rxae&bit != 0 && dynpd&bit == 0 && pw == 0 {
+			add(Warning, reg+", EN_RXADDR",
+				"pipe %d enabled but static payload width is 0", pn)
+		}
+	}
+	if ptx && aa&P0 != 0 &&
+		!bytes.Equal(s.RxAddr0[:aw], s.TxAddr[:aw]) {
+		add(Invalid, "RX_ADDR_P0, TX_ADDR",
+			"PTX receives ACK on pipe 0 so RX_ADDR_P0 must equal TX_ADDR")
+	}
+	return fs
+}
+
+// Snapshot returns register values that correspond to s. CONFIG register
+// contains only CRC bits.
+func (s *Settings) Snapshot() *Snapshot {
+	r := new(Snapshot)
+	r.Reg[0] = byte(s.crcCfg())
+	r.Reg[1] = byte(s.pipes(func(p *PipeSettings) bool { return p.AA }))
+	r.Reg[2] = byte(s.pipes(func(p *PipeSettings) bool { return p.Enabled }))
+	r.Reg[3] = byte(s.AW - 2)
+	r.Reg[4] = byte((s.RetrDelay/250-1)<<4 | s.Retr&0xf)
+	r.Reg[5] = byte(s.Ch)
+	r.Reg[6] = byte(s.rf())
+	r.RxAddr0 = s.Pipes[0].Addr
+	r.RxAddr1 = s.Pipes[1].Addr
+	r.TxAddr = s.TxAddr
+	for pn := 2; pn < 6; pn++ {
+		r.Reg[0x0a+pn] = s.Pipes[pn].Addr[0]
+	}
+	for pn := range s.Pipes {
+		r.Reg[0x11+pn] = byte(s.Pipes[pn].PW)
+	}
+	r.Reg[0x1c] = byte(s.pipes(func(p *PipeSettings) bool { return p.DPL }))
+	r.Reg[0x1d] = byte(s.Feature)
+	return r
+}
+
+// Validate checks s for inconsistent or invalid settings (see Validate) of
+// PRX (primRx == true) or PTX.
+func (s *Settings) Validate(primRx bool) []Finding {
+	r := s.Snapshot()
+	if primRx {
+		r.Reg[0] |= byte(PrimRx)
+	}
+	return Validate(r)
+}
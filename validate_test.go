@@ -0,0 +1,113 @@
+package nrf_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ziutek/nrf"
+)
+
+func TestMinARD(t *testing.T) {
+	tests := []struct {
+		dr            nrf.DataRate
+		aw, crc, plen int
+		want          int
+	}{
+		{nrf.DR1M, 5, 2, 0, 250},
+		{nrf.DR1M, 5, 2, 32, 500},
+		{nrf.DR2M, 5, 2, 32, 500},
+		{nrf.DR2M, 3, 1, 0, 250},
+		{nrf.DR250k, 5, 2, 0, 500},
+		{nrf.DR250k, 5, 2, 32, 1500},
+	}
+	for _, tc := range tests {
+		if ard := nrf.MinARD(tc.dr, tc.aw, tc.crc, tc.plen); ard != tc.want {
+			t.Errorf("MinARD(%v, %d, %d, %d) = %d, want %d",
+				tc.dr, tc.aw, tc.crc, tc.plen, ard, tc.want)
+		}
+	}
+}
+
+// find returns findings that concern register reg.
+func find(fs []nrf.Finding, reg string) []nrf.Finding {
+	var found []nrf.Finding
+	for _, f := range fs {
+		if strings.HasPrefix(f.Reg, reg) {
+			found = append(found, f)
+		}
+	}
+	return found
+}
+
+func validSettings() nrf.Settings {
+	s := nrf.Settings{
+		DataRate:  nrf.DR250k,
+		CRC:       2,
+		Ch:        76,
+		AW:        5,
+		Retr:      15,
+		RetrDelay: 1500,
+		TxAddr:    [5]byte{1, 2, 3, 4, 5},
+		Feature:   nrf.DPL | nrf.AckPay,
+	}
+	s.Pipes[0] = nrf.PipeSettings{Enabled: true, AA: true, DPL: true, Addr: s.TxAddr}
+	s.Pipes[1] = nrf.PipeSettings{AA: true, DPL: true}
+	return s
+}
+
+func TestValidateOK(t *testing.T) {
+	s := validSettings()
+	for _, primRx := range []bool{false, true} {
+		if fs := s.Validate(primRx); len(fs) != 0 {
+			t.Errorf("primRx=%t: %v", primRx, fs)
+		}
+	}
+}
+
+func TestValidateARD(t *testing.T) {
+	s := validSettings()
+	s.RetrDelay = 500 // Enough for empty ACK but not for 32 byte payload.
+	fs := find(s.Validate(false), "SETUP_RETR")
+	if len(fs) != 1 || fs[0].Severity != nrf.Warning {
+		t.Errorf("PTX: %v, want one warning", fs)
+	}
+	s.RetrDelay = 250 // Too short even for empty ACK.
+	fs = find(s.Validate(false), "SETUP_RETR")
+	if len(fs) != 1 || fs[0].Severity != nrf.Invalid {
+		t.Errorf("PTX: %v, want one invalid", fs)
+	}
+	if fs := s.Validate(true); len(fs) != 0 {
+		t.Errorf("PRX doesn't retransmit: %v", fs)
+	}
+}
+
+func TestValidatePTXAddr(t *testing.T) {
+	s := validSettings()
+	s.Pipes[0].Addr[0] = 9
+	fs := find(s.Validate(false), "RX_ADDR_P0")
+	if len(fs) != 1 || fs[0].Severity != nrf.Invalid {
+		t.Errorf("PTX: %v, want one invalid", fs)
+	}
+	if fs := s.Validate(true); len(fs) != 0 {
+		t.Errorf("PRX: %v", fs)
+	}
+}
+
+func TestValidateFeature(t *testing.T) {
+	s := validSettings()
+	s.Feature = nrf.AckPay
+	fs := s.Validate(true)
+	if nrf.Worst(fs) != nrf.Invalid {
+		t.Errorf("AckPay and DYNPD without DPL: %v", fs)
+	}
+	if len(find(fs, "FEATURE")) != 1 || len(find(fs, "DYNPD, FEATURE")) != 1 {
+		t.Errorf("findings: %v", fs)
+	}
+	s = validSettings()
+	s.Ch = 100
+	s.Pipes[2] = nrf.PipeSettings{Enabled: true}
+	fs = s.Validate(true)
+	if nrf.Worst(fs) != nrf.Warning || len(fs) != 2 {
+		t.Errorf("channel 100 and pipe 2 without width: %v", fs)
+	}
+}